@@ -0,0 +1,80 @@
+// Package apitest holds fixtures shared by tests of the API: a migrated
+// database and an organization with its admin and members.
+package apitest
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"io"
+	"testing"
+	"time"
+	"time_tracker/api/auth"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/user"
+	"time_tracker/config"
+	"time_tracker/db"
+)
+
+const (
+	Secret         = "test-secret"
+	MemberPassword = "member-password"
+)
+
+// Admin is the first admin of the organization created by CreateOrganization.
+var Admin = user.NewAdmin{
+	PassportNumber: "1000 100000",
+	Login:          "admin",
+	Password:       "admin-password",
+	Name:           "Ivan",
+	Surname:        "Ivanov",
+	Address:        "Moscow",
+}
+
+// Setup silences logs and configures tokens the way auth.Init does.
+func Setup() {
+	log.SetOutput(io.Discard)
+	auth.Secret, auth.AccessTTL, auth.RefreshTTL = []byte(Secret), time.Minute, time.Hour
+}
+
+// NewDB returns a migrated in-memory SQLite database closed when the test ends.
+func NewDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	c := &config.Config{Config: config.EnvFileConfig{Driver: db.DriverSQLite, Path: ":memory:"}}
+	DB := db.Connect(c, logger.Silent)
+	t.Cleanup(func() { db.Close(DB) })
+
+	_, err := db.MigrateUp(DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return DB
+}
+
+// CreateOrganization creates the Acme organization with Admin.
+func CreateOrganization(t *testing.T, store user.Store, enricher enrichment.Provider) (user.Organization, user.FullUser) {
+	t.Helper()
+
+	org, admin, err := user.CreateOrganization(context.Background(), store, enricher,
+		user.NewOrganization{Name: "Acme", Admin: Admin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return org, admin
+}
+
+// NewMember is the request registering a member in the organization with
+// the join code, its password is MemberPassword.
+func NewMember(login, passport, joinCode string) user.NewUser {
+	return user.NewUser{
+		PassportNumber:   passport,
+		Login:            login,
+		Password:         MemberPassword,
+		OrganizationCode: joinCode,
+		Name:             "Petr",
+		Surname:          "Petrov",
+		Address:          "Kazan",
+	}
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"strings"
+	"time_tracker/api/service"
+)
+
+// Handler authenticates requests and serves API key endpoints. Principals
+// are checked against Users, so deleted users are rejected even with tokens
+// that didn't expire yet.
+type Handler struct {
+	Keys  APIKeyRepository
+	Users Users
+}
+
+func NewHandler(keys APIKeyRepository, users Users) *Handler {
+	return &Handler{Keys: keys, Users: users}
+}
+
+// CreateAPIKeyHandler godoc
+//
+//	@Summary		Create API key
+//	@Description	Create personal API key for scripts and integrations. The key is shown only once.
+//	@Description	Keys without scopes have full access, known scopes: tasks:read, tasks:write, reports:read, users:read, users:write.
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			New	key			body	NewAPIKey	true	"Key name and optional scopes"
+//	@Success		201	{object}	service.OkResponse{data=CreatedAPIKey}
+//	@Failure		400	{object}	service.ErrorResponse
+//	@Failure		401	{object}	service.ErrorResponse
+//	@Failure		403	{object}	service.ErrorResponse
+//	@Failure		413	{object}	service.ErrorResponse
+//	@Failure		500	{object}	service.ErrorResponse
+//	@Router			/auth/keys [post]
+func (h *Handler) CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	userId, ok := UserId(r)
+	if !ok {
+		e.Error401(errors.New("user can't be determined"))
+		service.ServerResponse(w, e)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		e.ReadBodyError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+	defer r.Body.Close()
+
+	var newKey NewAPIKey
+	err = service.DeserializeJSON(data, &newKey)
+	if err != nil {
+		e.DeserializeError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	err = validateNewAPIKey(&newKey)
+	if err != nil {
+		e.ValidationError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	plain, prefix, hash, err := generateAPIKey()
+	if err != nil {
+		e.Error500(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	key := APIKey{
+		KeyId:   uuid.New(),
+		UserId:  userId,
+		Name:    newKey.Name,
+		Prefix:  prefix,
+		KeyHash: hash,
+		Scopes:  strings.Join(newKey.Scopes, ","),
+	}
+
+	err = h.Keys.Create(&key)
+	if err != nil {
+		e.DBError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	msg := "API key created successfully"
+
+	service.ServerResponse(w, service.OkResponse{
+		Code:    http.StatusCreated,
+		Message: msg,
+		Data:    CreatedAPIKey{Key: plain, APIKey: key},
+	})
+	log.WithField("key_id", key.KeyId).Info(msg)
+}
+
+// ReadAPIKeysHandler godoc
+//
+//	@Summary		Get API keys
+//	@Description	Get API keys of the authenticated user
+//	@Tags			Auth
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{array}		APIKey
+//	@Failure		401	{object}	service.ErrorResponse
+//	@Failure		403	{object}	service.ErrorResponse
+//	@Failure		500	{object}	service.ErrorResponse
+//	@Router			/auth/keys [get]
+func (h *Handler) ReadAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	userId, ok := UserId(r)
+	if !ok {
+		e.Error401(errors.New("user can't be determined"))
+		service.ServerResponse(w, e)
+		return
+	}
+
+	keys, err := h.Keys.ReadMany(userId)
+	if err != nil {
+		e.DBError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	service.ServerResponse(w, keys)
+	log.Info("API keys read successfully")
+}
+
+// RevokeAPIKeyHandler godoc
+//
+//	@Summary		Revoke API key
+//	@Description	Revoke API key by UUID
+//	@Tags			Auth
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			uuid	path		string	true	"Provide key's uuid"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	service.ErrorResponse
+//	@Failure		401		{object}	service.ErrorResponse
+//	@Failure		403		{object}	service.ErrorResponse
+//	@Failure		404		{object}	service.ErrorResponse
+//	@Failure		500		{object}	service.ErrorResponse
+//	@Router			/auth/keys/{uuid} [delete]
+func (h *Handler) RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	userId, ok := UserId(r)
+	if !ok {
+		e.Error401(errors.New("user can't be determined"))
+		service.ServerResponse(w, e)
+		return
+	}
+
+	keyId, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		e.UuidParseError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	err = h.Keys.Revoke(userId, keyId)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	msg := "API key revoked successfully"
+
+	service.ServerResponse(w, service.OkResponse{
+		Code:    http.StatusNoContent,
+		Message: msg,
+		Data:    "",
+	})
+	log.Info(msg)
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"net/http"
+	"strings"
+	"time"
+	"time_tracker/api/validation"
+)
+
+const (
+	accessTokenType  = "access"
+	refreshTokenType = "refresh"
+
+	apiKeyPrefix       = "tt_"
+	apiKeyPrefixLength = 11
+)
+
+func IssueTokenPair(userId uuid.UUID) (TokenPair, error) {
+	access, err := issueToken(userId, accessTokenType, AccessTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refresh, err := issueToken(userId, refreshTokenType, RefreshTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(AccessTTL.Seconds()),
+	}, nil
+}
+
+func issueToken(userId uuid.UUID, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userId.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			ID:        uuid.NewString(),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(Secret)
+}
+
+func parseToken(raw, tokenType string) (uuid.UUID, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (interface{}, error) {
+		return Secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if claims.TokenType != tokenType {
+		return uuid.Nil, fmt.Errorf("expected %s token, got %q", tokenType, claims.TokenType)
+	}
+
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, errors.New("invalid token subject")
+	}
+
+	return userId, nil
+}
+
+func ParseRefreshToken(raw string) (uuid.UUID, error) {
+	return parseToken(raw, refreshTokenType)
+}
+
+// authorizationToken extracts the credential from 'Bearer <token>' or
+// 'ApiKey <key>' Authorization header.
+func authorizationToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", errors.New("authorization header is missing")
+	}
+
+	scheme, token, found := strings.Cut(header, " ")
+	token = strings.TrimSpace(token)
+	if !found || token == "" || !(strings.EqualFold(scheme, "Bearer") || strings.EqualFold(scheme, "ApiKey")) {
+		return "", errors.New("authorization header must be in format 'Bearer <token>'")
+	}
+
+	return token, nil
+}
+
+// generateAPIKey returns the plain key shown to the user once, its display
+// prefix and the hash stored in the database.
+func generateAPIKey() (string, string, string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:apiKeyPrefixLength], hashAPIKey(key), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func validateNewAPIKey(k *NewAPIKey) error {
+	v := validation.New()
+	v.Required("name", k.Name)
+
+	for _, scope := range k.Scopes {
+		v.Check(knownScopes[scope], "scopes", validation.RuleOneOf, fmt.Sprintf("unknown scope %q", scope))
+	}
+
+	return v.Err()
+}
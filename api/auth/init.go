@@ -0,0 +1,26 @@
+package auth
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"time"
+)
+
+var (
+	Secret     []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+)
+
+// Init sets token settings and returns the API keys repository backed by d.
+func Init(d *gorm.DB, secret string, accessTTL, refreshTTL time.Duration) *GormRepository {
+	if secret == "" {
+		log.Fatal("JWT secret is not set")
+	}
+
+	Secret = []byte(secret)
+	AccessTTL = accessTTL
+	RefreshTTL = refreshTTL
+	log.Info("Auth init success")
+	return NewGormRepository(d)
+}
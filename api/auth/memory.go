@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+	"time_tracker/api/service"
+)
+
+// MemoryRepository keeps API keys in memory and is meant for tests.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	nextId uint
+	keys   []APIKey
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{}
+}
+
+func (m *MemoryRepository) Create(key *APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextId++
+	now := time.Now()
+	key.ID, key.CreatedAt, key.UpdatedAt = m.nextId, now, now
+	m.keys = append(m.keys, *key)
+	return nil
+}
+
+func (m *MemoryRepository) ReadByHash(hash string) (APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, key := range m.keys {
+		if !key.DeletedAt.Valid && key.KeyHash == hash {
+			return key, nil
+		}
+	}
+	return APIKey{}, gorm.ErrRecordNotFound
+}
+
+func (m *MemoryRepository) ReadMany(userId uuid.UUID) ([]APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var keys []APIKey
+	for _, key := range m.keys {
+		if !key.DeletedAt.Valid && key.UserId == userId {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *MemoryRepository) Revoke(userId, keyId uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, key := range m.keys {
+		if !key.DeletedAt.Valid && key.KeyId == keyId && key.UserId == userId {
+			m.keys[i].DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+			return nil
+		}
+	}
+	return service.ErrNotFound
+}
+
+func (m *MemoryRepository) RevokeAll(userId uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, key := range m.keys {
+		if !key.DeletedAt.Valid && key.UserId == userId {
+			m.keys[i].DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		}
+	}
+	return nil
+}
+
+func (m *MemoryRepository) Touch(key *APIKey, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key.LastUsedAt = &at
+	for i := range m.keys {
+		if m.keys[i].ID == key.ID {
+			m.keys[i].LastUsedAt = &at
+		}
+	}
+	return nil
+}
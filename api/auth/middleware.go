@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"time_tracker/api/service"
+)
+
+const (
+	ScopeTasksRead   = "tasks:read"
+	ScopeTasksWrite  = "tasks:write"
+	ScopeReportsRead = "reports:read"
+	ScopeUsersRead   = "users:read"
+	ScopeUsersWrite  = "users:write"
+)
+
+var knownScopes = map[string]bool{
+	ScopeTasksRead:   true,
+	ScopeTasksWrite:  true,
+	ScopeReportsRead: true,
+	ScopeUsersRead:   true,
+	ScopeUsersWrite:  true,
+}
+
+type contextKey int
+
+const principalKey contextKey = iota
+
+// principal describes who made the request. Scopes are only set for API keys,
+// requests authenticated with an access token are not limited by scopes.
+type principal struct {
+	userId uuid.UUID
+	apiKey bool
+	scopes []string
+}
+
+// publicRoutes can be requested without an access token.
+var publicRoutes = map[string]bool{
+	"GET /api/v1":               true,
+	"GET /api/v1/health":        true,
+	"POST /api/v1/user":         true,
+	"POST /api/v1/auth/login":   true,
+	"POST /api/v1/auth/refresh": true,
+}
+
+// Middleware authenticates requests to non-public routes with an access token
+// or an API key and stores the principal in the request context.
+func (h *Handler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublic(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var e service.ErrorResponse
+
+		token, err := authorizationToken(r)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			e.Error401(err)
+			service.ServerResponse(w, e)
+			return
+		}
+
+		var p principal
+		if strings.HasPrefix(token, apiKeyPrefix) {
+			p, err = h.apiKeyPrincipal(token)
+		} else {
+			p.userId, err = parseToken(token, accessTokenType)
+		}
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			e.Error401(err)
+			service.ServerResponse(w, e)
+			return
+		}
+
+		exists, err := h.Users.Exists(p.userId)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			e.DBError(err)
+			service.ServerResponse(w, e)
+			return
+		}
+		if !exists {
+			w.Header().Set("Content-Type", "application/json")
+			e.Error401(errors.New("user not found"))
+			service.ServerResponse(w, e)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey, p)))
+	})
+}
+
+// Scoped allows the request only if it was made with an access token or
+// with an API key granted the scope. Keys without scopes have full access.
+func Scoped(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := r.Context().Value(principalKey).(principal)
+		if ok && p.apiKey && len(p.scopes) > 0 && !slices.Contains(p.scopes, scope) {
+			var e service.ErrorResponse
+			w.Header().Set("Content-Type", "application/json")
+			e.Error403(errors.New("API key lacks scope " + scope))
+			service.ServerResponse(w, e)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// SessionOnly rejects requests authenticated with an API key.
+func SessionOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := r.Context().Value(principalKey).(principal)
+		if ok && p.apiKey {
+			var e service.ErrorResponse
+			w.Header().Set("Content-Type", "application/json")
+			e.Error403(errors.New("API keys can't be used for this request"))
+			service.ServerResponse(w, e)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// UserId returns the UUID of the authenticated user making the request.
+func UserId(r *http.Request) (uuid.UUID, bool) {
+	p, ok := r.Context().Value(principalKey).(principal)
+	return p.userId, ok
+}
+
+func (h *Handler) apiKeyPrincipal(token string) (principal, error) {
+	key, err := h.Keys.ReadByHash(hashAPIKey(token))
+	if err != nil {
+		return principal{}, errors.New("invalid API key")
+	}
+
+	now := time.Now()
+	if key.touchDue(now) {
+		err = h.Keys.Touch(&key, now)
+		if err != nil {
+			log.WithField("key_id", key.KeyId).Warn("API key last used update failed: ", err)
+		}
+	}
+
+	return principal{userId: key.UserId, apiKey: true, scopes: key.ScopeList()}, nil
+}
+
+func isPublic(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		return true
+	}
+	return publicRoutes[r.Method+" "+strings.TrimSuffix(r.URL.Path, "/")]
+}
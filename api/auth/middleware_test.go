@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testUsers holds ids of existing users.
+type testUsers map[uuid.UUID]bool
+
+func (u testUsers) Exists(userId uuid.UUID) (bool, error) {
+	return u[userId], nil
+}
+
+// newTestRouter protects a route of every kind with the middleware, handlers
+// answer with the id of the authenticated user.
+func newTestRouter(keys APIKeyRepository, users Users) http.Handler {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		userId, _ := UserId(r)
+		io.WriteString(w, userId.String())
+	}
+
+	router := http.NewServeMux()
+	router.HandleFunc("GET /api/v1/health", ok)
+	router.HandleFunc("GET /api/v1/tasks/{uuid}", Scoped(ScopeTasksRead, ok))
+	router.HandleFunc("POST /api/v1/task", Scoped(ScopeTasksWrite, ok))
+	router.HandleFunc("GET /api/v1/auth/keys", SessionOnly(ok))
+	return NewHandler(keys, users).Middleware(router)
+}
+
+func createTestKey(t *testing.T, keys APIKeyRepository, userId uuid.UUID, scopes string) string {
+	t.Helper()
+
+	key, prefix, hash, err := generateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = keys.Create(&APIKey{KeyId: uuid.New(), UserId: userId, Name: "CI", Prefix: prefix, KeyHash: hash, Scopes: scopes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestMiddleware(t *testing.T) {
+	log.SetOutput(io.Discard)
+	Secret, AccessTTL, RefreshTTL = []byte("test-secret"), time.Minute, time.Hour
+
+	keys := NewMemoryRepository()
+	userId, deletedId := uuid.New(), uuid.New()
+	router := newTestRouter(keys, testUsers{userId: true})
+
+	tokens, err := IssueTokenPair(userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deletedTokens, err := IssueTokenPair(deletedId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deletedKey := createTestKey(t, keys, deletedId, "")
+	readKey := createTestKey(t, keys, userId, ScopeTasksRead)
+	fullKey := createTestKey(t, keys, userId, "")
+	revokedKey := createTestKey(t, keys, userId, "")
+	revoked, _ := keys.ReadByHash(hashAPIKey(revokedKey))
+	err = keys.Revoke(userId, revoked.KeyId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"public route", "GET", "/api/v1/health", "", http.StatusOK},
+		{"no token", "GET", "/api/v1/tasks/1", "", http.StatusUnauthorized},
+		{"malformed token", "GET", "/api/v1/tasks/1", "garbage", http.StatusUnauthorized},
+		{"refresh token", "GET", "/api/v1/tasks/1", tokens.RefreshToken, http.StatusUnauthorized},
+		{"access token", "POST", "/api/v1/task", tokens.AccessToken, http.StatusOK},
+		{"access token, session only", "GET", "/api/v1/auth/keys", tokens.AccessToken, http.StatusOK},
+		{"key with scope", "GET", "/api/v1/tasks/1", readKey, http.StatusOK},
+		{"key without scope", "POST", "/api/v1/task", readKey, http.StatusForbidden},
+		{"key without scopes", "POST", "/api/v1/task", fullKey, http.StatusOK},
+		{"key, session only", "GET", "/api/v1/auth/keys", fullKey, http.StatusForbidden},
+		{"unknown key", "GET", "/api/v1/tasks/1", apiKeyPrefix + "unknown", http.StatusUnauthorized},
+		{"revoked key", "GET", "/api/v1/tasks/1", revokedKey, http.StatusUnauthorized},
+		{"token of deleted user", "GET", "/api/v1/auth/keys", deletedTokens.AccessToken, http.StatusUnauthorized},
+		{"key of deleted user", "GET", "/api/v1/tasks/1", deletedKey, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			if tt.status == http.StatusOK && tt.token != "" && w.Body.String() != userId.String() {
+				t.Fatalf("expected user %s, got %s", userId, w.Body)
+			}
+		})
+	}
+
+	used, err := keys.ReadByHash(hashAPIKey(readKey))
+	if err != nil || used.LastUsedAt == nil {
+		t.Fatalf("expected last use of the key stored, got %v, %v", used.LastUsedAt, err)
+	}
+}
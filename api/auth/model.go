@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token" extensions:"x-order=1"`
+	RefreshToken string `json:"refresh_token" extensions:"x-order=2"`
+	TokenType    string `json:"token_type" example:"Bearer" extensions:"x-order=3"`
+	ExpiresIn    int64  `json:"expires_in" example:"900" extensions:"x-order=4"`
+}
+
+type Claims struct {
+	TokenType string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+type APIKey struct {
+	ID         uint           `json:"-" gorm:"primarykey"`
+	KeyId      uuid.UUID      `json:"key_id" example:"00000000-0000-0000-0000-000000000000" extensions:"x-order=1"`
+	UserId     uuid.UUID      `json:"-" gorm:"index"`
+	Name       string         `json:"name" example:"CI" extensions:"x-order=2"`
+	Prefix     string         `json:"prefix" example:"tt_AbCd1234" extensions:"x-order=3"`
+	KeyHash    string         `json:"-" gorm:"uniqueIndex"`
+	Scopes     string         `json:"scopes" example:"tasks:write,reports:read" extensions:"x-order=4"`
+	LastUsedAt *time.Time     `json:"last_used_at" extensions:"x-order=5"`
+	CreatedAt  time.Time      `json:"created_at" extensions:"x-order=6"`
+	UpdatedAt  time.Time      `json:"-"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+type NewAPIKey struct {
+	Name   string   `json:"name" example:"CI" extensions:"x-order=1"`
+	Scopes []string `json:"scopes" example:"tasks:write,reports:read" extensions:"x-order=2"`
+}
+
+type CreatedAPIKey struct {
+	Key    string `json:"key" extensions:"x-order=1"`
+	APIKey APIKey `json:"api_key" extensions:"x-order=2"`
+}
+
+func (a *APIKey) TableName() string {
+	return "api_keys"
+}
+
+// touchDue reports whether last use at now should be stored. Writes are
+// throttled to one per minute so that busy scripts don't update the row on
+// every request.
+func (a *APIKey) touchDue(now time.Time) bool {
+	return a.LastUsedAt == nil || now.Sub(*a.LastUsedAt) >= time.Minute
+}
+
+func (a *APIKey) ScopeList() []string {
+	if a.Scopes == "" {
+		return nil
+	}
+	return strings.Split(a.Scopes, ",")
+}
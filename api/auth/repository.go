@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+	"time_tracker/api/service"
+)
+
+// APIKeyRepository stores personal API keys, plain keys are never stored.
+type APIKeyRepository interface {
+	Create(key *APIKey) error
+	ReadByHash(hash string) (APIKey, error)
+	ReadMany(userId uuid.UUID) ([]APIKey, error)
+	Revoke(userId, keyId uuid.UUID) error
+	// RevokeAll revokes all keys of the user, it is called when the user is deleted.
+	RevokeAll(userId uuid.UUID) error
+	Touch(key *APIKey, at time.Time) error
+}
+
+// Users is the part of user storage principals are checked against. It is
+// implemented by the user package, which depends on this one.
+type Users interface {
+	// Exists reports whether the user exists and isn't deleted.
+	Exists(userId uuid.UUID) (bool, error)
+}
+
+type GormRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRepository(db *gorm.DB) *GormRepository {
+	return &GormRepository{db: db}
+}
+
+func (g *GormRepository) Create(key *APIKey) error {
+	err := g.db.Create(key).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (g *GormRepository) ReadByHash(hash string) (APIKey, error) {
+	var key APIKey
+	err := g.db.Where("key_hash = ?", hash).First(&key).Error
+	if err != nil {
+		return APIKey{}, err
+	}
+	return key, nil
+}
+
+func (g *GormRepository) ReadMany(userId uuid.UUID) ([]APIKey, error) {
+	var keys []APIKey
+	err := g.db.Where("user_id = ?", userId).Order("id").Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (g *GormRepository) Revoke(userId, keyId uuid.UUID) error {
+	result := g.db.Where("key_id = ? AND user_id = ?", keyId, userId).Delete(&APIKey{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return service.ErrNotFound
+	}
+
+	return nil
+}
+
+func (g *GormRepository) RevokeAll(userId uuid.UUID) error {
+	return g.db.Where("user_id = ?", userId).Delete(&APIKey{}).Error
+}
+
+func (g *GormRepository) Touch(key *APIKey, at time.Time) error {
+	key.LastUsedAt = &at
+	return g.db.Model(&APIKey{}).Where("id = ?", key.ID).Update("last_used_at", at).Error
+}
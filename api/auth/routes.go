@@ -0,0 +1,9 @@
+package auth
+
+import "net/http"
+
+func (h *Handler) AddRoutes(router *http.ServeMux) {
+	router.HandleFunc("POST /api/v1/auth/keys", SessionOnly(h.CreateAPIKeyHandler))
+	router.HandleFunc("GET /api/v1/auth/keys", SessionOnly(h.ReadAPIKeysHandler))
+	router.HandleFunc("DELETE /api/v1/auth/keys/{uuid}", SessionOnly(h.RevokeAPIKeyHandler))
+}
@@ -0,0 +1,94 @@
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	CacheMemory = "memory"
+	CacheDB     = "db"
+	CacheNone   = "none"
+)
+
+// Cache keeps people found by passport for a limited time. Only found people
+// are cached, lookups which failed are repeated.
+type Cache interface {
+	// Get returns false if the passport isn't cached or its entry expired.
+	Get(ctx context.Context, serie, number int) (Person, bool, error)
+	Set(ctx context.Context, serie, number int, p Person) error
+}
+
+// NewCache returns the cache chosen by kind with entries living for ttl, nil for none.
+func NewCache(kind string, d *gorm.DB, ttl time.Duration) (Cache, error) {
+	switch kind {
+	case CacheNone, "":
+		return nil, nil
+	case CacheMemory:
+		return NewMemoryCache(ttl), nil
+	case CacheDB:
+		return NewGormCache(d, ttl), nil
+	default:
+		return nil, fmt.Errorf("unknown enrichment cache %q, use %s, %s or %s",
+			kind, CacheMemory, CacheDB, CacheNone)
+	}
+}
+
+// CacheStats counts lookups of CachingProvider since start. Errors are cache
+// failures, the provider is asked in that case.
+type CacheStats struct {
+	Hits   int64 `json:"hits" extensions:"x-order=1"`
+	Misses int64 `json:"misses" extensions:"x-order=2"`
+	Errors int64 `json:"errors" extensions:"x-order=3"`
+}
+
+// CachingProvider asks Provider only for passports missing in Cache, fresh
+// requests go to Provider and update the cache.
+type CachingProvider struct {
+	Provider Provider
+	Cache    Cache
+
+	hits, misses, errors atomic.Int64
+}
+
+func NewCachingProvider(provider Provider, cache Cache) *CachingProvider {
+	return &CachingProvider{Provider: provider, Cache: cache}
+}
+
+func (c *CachingProvider) Enrich(ctx context.Context, req Request) (Person, error) {
+	logger := log.WithFields(log.Fields{"serie": req.PassportSerie, "number": req.PassportNumber})
+
+	if !req.Fresh {
+		p, ok, err := c.Cache.Get(ctx, req.PassportSerie, req.PassportNumber)
+		if err != nil {
+			c.errors.Add(1)
+			logger.Warn("Enrichment cache read failed: ", err)
+		}
+		if ok {
+			c.hits.Add(1)
+			logger.Debug("Enrichment cache hit")
+			return p, nil
+		}
+		c.misses.Add(1)
+	}
+
+	p, err := c.Provider.Enrich(ctx, req)
+	if err != nil {
+		return Person{}, err
+	}
+
+	err = c.Cache.Set(ctx, req.PassportSerie, req.PassportNumber, p)
+	if err != nil {
+		c.errors.Add(1)
+		logger.Warn("Enrichment cache write failed: ", err)
+	}
+	return p, nil
+}
+
+func (c *CachingProvider) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Errors: c.errors.Load()}
+}
@@ -0,0 +1,163 @@
+package enrichment_test
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+	"time_tracker/api/apitest"
+	"time_tracker/api/enrichment"
+)
+
+var (
+	ivan  = enrichment.Person{Name: "Ivan", Surname: "Ivanov", Address: "Moscow"}
+	petr  = enrichment.Person{Name: "Petr", Surname: "Petrov", Address: "Kazan"}
+	empty = enrichment.Person{}
+)
+
+// cacheBackends return caches of every kind with entries living for ttl,
+// the database is nil for the memory cache.
+var cacheBackends = []struct {
+	name  string
+	setup func(t *testing.T, ttl time.Duration) (enrichment.Cache, *gorm.DB)
+}{
+	{"memory", func(t *testing.T, ttl time.Duration) (enrichment.Cache, *gorm.DB) {
+		return newCache(t, enrichment.CacheMemory, nil, ttl), nil
+	}},
+	{"sqlite", func(t *testing.T, ttl time.Duration) (enrichment.Cache, *gorm.DB) {
+		DB := apitest.NewDB(t)
+		return newCache(t, enrichment.CacheDB, DB, ttl), DB
+	}},
+}
+
+func newCache(t *testing.T, kind string, DB *gorm.DB, ttl time.Duration) enrichment.Cache {
+	t.Helper()
+
+	cache, err := enrichment.NewCache(kind, DB, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
+
+// step sets the person of the passport, if not empty, after waiting for wait
+// and expects get to find want.
+type step struct {
+	serie, number int
+	set           enrichment.Person
+	wait          time.Duration
+	want          enrichment.Person
+	hit           bool
+}
+
+func TestCache(t *testing.T) {
+	tests := []struct {
+		name  string
+		ttl   time.Duration
+		steps []step
+		rows  int64
+	}{
+		{"miss", time.Hour, []step{
+			{serie: 1000, number: 100000},
+		}, 0},
+		{"hit", time.Hour, []step{
+			{serie: 1000, number: 100000, set: ivan, want: ivan, hit: true},
+			{serie: 1000, number: 200000},
+			{serie: 2000, number: 100000},
+		}, 1},
+		{"upsert on conflict", time.Hour, []step{
+			{serie: 1000, number: 100000, set: ivan, want: ivan, hit: true},
+			{serie: 1000, number: 100000, set: petr, want: petr, hit: true},
+		}, 1},
+		{"ttl expiry", 50 * time.Millisecond, []step{
+			{serie: 1000, number: 100000, set: ivan, want: ivan, hit: true},
+			{serie: 1000, number: 100000, wait: 60 * time.Millisecond},
+		}, 1},
+		{"expired entry overwritten", 50 * time.Millisecond, []step{
+			{serie: 1000, number: 100000, set: ivan, want: ivan, hit: true},
+			{serie: 1000, number: 100000, wait: 60 * time.Millisecond, set: petr, want: petr, hit: true},
+		}, 1},
+	}
+
+	for _, backend := range cacheBackends {
+		for _, tt := range tests {
+			t.Run(backend.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				cache, DB := backend.setup(t, tt.ttl)
+
+				for i, s := range tt.steps {
+					time.Sleep(s.wait)
+					if s.set != empty {
+						err := cache.Set(ctx, s.serie, s.number, s.set)
+						if err != nil {
+							t.Fatalf("step %d: %v", i, err)
+						}
+					}
+
+					p, ok, err := cache.Get(ctx, s.serie, s.number)
+					if err != nil {
+						t.Fatalf("step %d: %v", i, err)
+					}
+					if ok != s.hit || p != s.want {
+						t.Fatalf("step %d: expected %+v, %t, got %+v, %t", i, s.want, s.hit, p, ok)
+					}
+				}
+
+				if DB == nil {
+					return
+				}
+				var rows int64
+				err := DB.Model(&enrichment.CachedPerson{}).Count(&rows).Error
+				if err != nil || rows != tt.rows {
+					t.Fatalf("expected %d cached rows, got %d, %v", tt.rows, rows, err)
+				}
+			})
+		}
+	}
+}
+
+// countingProvider returns the submitted person and counts calls.
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Enrich(ctx context.Context, req enrichment.Request) (enrichment.Person, error) {
+	p.calls++
+	return req.Submitted, nil
+}
+
+func TestCachingProvider(t *testing.T) {
+	for _, backend := range cacheBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			ctx := context.Background()
+			cache, _ := backend.setup(t, time.Hour)
+			provider := &countingProvider{}
+			caching := enrichment.NewCachingProvider(provider, cache)
+
+			req := enrichment.Request{PassportSerie: 1000, PassportNumber: 100000, Submitted: ivan}
+			for i := 0; i < 2; i++ {
+				p, err := caching.Enrich(ctx, req)
+				if err != nil || p != ivan {
+					t.Fatalf("expected %+v, got %+v, %v", ivan, p, err)
+				}
+			}
+
+			// fresh requests skip the cache and update it
+			req.Submitted, req.Fresh = petr, true
+			p, err := caching.Enrich(ctx, req)
+			if err != nil || p != petr {
+				t.Fatalf("expected %+v, got %+v, %v", petr, p, err)
+			}
+			req.Submitted, req.Fresh = ivan, false
+			p, err = caching.Enrich(ctx, req)
+			if err != nil || p != petr {
+				t.Fatalf("expected the fresh person cached, got %+v, %v", p, err)
+			}
+
+			stats := caching.Stats()
+			if provider.calls != 2 || stats.Hits != 2 || stats.Misses != 1 || stats.Errors != 0 {
+				t.Fatalf("expected 2 calls, 2 hits and 1 miss, got %d calls and %+v", provider.calls, stats)
+			}
+		})
+	}
+}
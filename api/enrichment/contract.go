@@ -0,0 +1,148 @@
+package enrichment
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"sort"
+	"strings"
+	"time_tracker/api/service"
+)
+
+// InfoSchema is the JSON Schema of the /info response of the people info API.
+//
+//go:embed info.schema.json
+var InfoSchema []byte
+
+// objectSchema is the part of JSON Schema InfoSchema is written in: an object
+// with typed properties, required ones and, optionally, no other ones.
+type objectSchema struct {
+	Type                 string                `json:"type"`
+	Properties           map[string]typeSchema `json:"properties"`
+	Required             []string              `json:"required"`
+	AdditionalProperties *bool                 `json:"additionalProperties"`
+}
+
+type typeSchema struct {
+	Type string `json:"type"`
+}
+
+var infoSchema = mustParseSchema(InfoSchema)
+
+func mustParseSchema(data []byte) objectSchema {
+	var s objectSchema
+	err := json.Unmarshal(data, &s)
+	if err != nil || s.Type != "object" {
+		panic(fmt.Sprintf("invalid object schema: %v", err))
+	}
+	return s
+}
+
+// ContractError lists differences of a response from the contract, fields
+// unknown to it are listed apart. It wraps ErrExternalAPI.
+type ContractError struct {
+	Violations []string
+	Unknown    []string
+}
+
+func (c *ContractError) Error() string {
+	problems := append([]string(nil), c.Violations...)
+	for _, field := range c.Unknown {
+		problems = append(problems, field+": unknown field")
+	}
+	return "response violates the /info contract: " + strings.Join(problems, "; ")
+}
+
+func (c *ContractError) Unwrap() error {
+	return service.ErrExternalAPI
+}
+
+// CheckInfo validates the /info response against InfoSchema, nil means it
+// conforms, unknown fields included.
+func CheckInfo(data []byte) *ContractError {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil || fields == nil {
+		return &ContractError{Violations: []string{"/: expected object, got " + jsonType(data)}}
+	}
+
+	c := &ContractError{}
+	for _, name := range infoSchema.Required {
+		if _, ok := fields[name]; !ok {
+			c.Violations = append(c.Violations, name+": required field is missing")
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := infoSchema.Properties[name]
+		if !ok {
+			if infoSchema.AdditionalProperties != nil && !*infoSchema.AdditionalProperties {
+				c.Unknown = append(c.Unknown, name)
+			}
+			continue
+		}
+
+		got := jsonType(fields[name])
+		if got != property.Type && !(property.Type == "number" && got == "integer") {
+			c.Violations = append(c.Violations, fmt.Sprintf("%s: expected %s, got %s", name, property.Type, got))
+		}
+	}
+
+	if len(c.Violations) == 0 && len(c.Unknown) == 0 {
+		return nil
+	}
+	return c
+}
+
+// DecodeInfo decodes the /info response checked by CheckInfo. Unknown fields
+// are an error only if strict, otherwise they are logged and ignored.
+func DecodeInfo(data []byte, strict bool) (Person, error) {
+	c := CheckInfo(data)
+	if c != nil && (len(c.Violations) > 0 || strict) {
+		return Person{}, c
+	}
+	if c != nil {
+		log.WithField("fields", c.Unknown).Warn("External API response has fields unknown to the contract")
+	}
+
+	var p Person
+	err := json.Unmarshal(data, &p)
+	if err != nil {
+		return Person{}, err
+	}
+	return p, nil
+}
+
+// jsonType names the JSON Schema type of the value.
+func jsonType(value []byte) string {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		return "nothing"
+	}
+
+	switch value[0] {
+	case '"':
+		return "string"
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	}
+
+	if bytes.ContainsAny(value, ".eE") {
+		return "number"
+	}
+	return "integer"
+}
@@ -0,0 +1,150 @@
+package enrichment_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/external"
+	"time_tracker/api/external/mock"
+	"time_tracker/api/service"
+)
+
+const fixtures = "../../fixtures/people.json"
+
+// drift changes found people in responses of next: sets fields of set and
+// removes fields of remove, the way the real API could break the contract.
+func drift(next http.Handler, set map[string]interface{}, remove ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, r)
+
+		var fields map[string]interface{}
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &fields) != nil {
+			w.WriteHeader(rec.Code)
+			w.Write(rec.Body.Bytes())
+			return
+		}
+
+		for name, value := range set {
+			fields[name] = value
+		}
+		for _, name := range remove {
+			delete(fields, name)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(fields)
+	})
+}
+
+// runContract runs the contract suite of the fixtures against the mock
+// wrapped by wrap and returns failed cases by name and the number of cases
+// expecting a person found.
+func runContract(t *testing.T, wrap func(http.Handler) http.Handler) (map[string]error, int) {
+	t.Helper()
+	log.SetOutput(io.Discard)
+
+	people, err := enrichment.NewFileProvider(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(wrap(mock.New(people, mock.Config{}).Handler()))
+	t.Cleanup(ts.Close)
+
+	cases := enrichment.ContractCases(people)
+	if len(cases) < 2 {
+		t.Fatalf("expected cases for every person and a missing one, got %d", len(cases))
+	}
+
+	client := external.New(external.Config{BaseURL: ts.URL, Timeout: time.Second})
+	failed := map[string]error{}
+	for _, res := range enrichment.RunContract(context.Background(), client, cases) {
+		if res.Err != nil {
+			failed[res.Case.Name] = res.Err
+		}
+	}
+	return failed, len(cases) - 1
+}
+
+func TestContractMock(t *testing.T) {
+	failed, _ := runContract(t, func(h http.Handler) http.Handler { return h })
+	if len(failed) > 0 {
+		t.Fatalf("expected the mock to conform to the contract, failed: %v", failed)
+	}
+}
+
+func TestContractUnknownField(t *testing.T) {
+	failed, found := runContract(t, func(h http.Handler) http.Handler {
+		return drift(h, map[string]interface{}{"birthDate": "1990-01-01"})
+	})
+	if len(failed) != found {
+		t.Fatalf("expected every found person to fail, failed: %v", failed)
+	}
+
+	for name, err := range failed {
+		var c *enrichment.ContractError
+		if !errors.As(err, &c) || len(c.Unknown) != 1 || c.Unknown[0] != "birthDate" || len(c.Violations) > 0 {
+			t.Fatalf("%s: expected unknown birthDate, got %v", name, err)
+		}
+		if !errors.Is(err, service.ErrExternalAPI) {
+			t.Fatalf("%s: expected external API error, got %v", name, err)
+		}
+	}
+}
+
+func TestContractViolations(t *testing.T) {
+	failed, found := runContract(t, func(h http.Handler) http.Handler {
+		return drift(h, map[string]interface{}{"address": 5}, "surname")
+	})
+	if len(failed) != found {
+		t.Fatalf("expected every found person to fail, failed: %v", failed)
+	}
+
+	for name, err := range failed {
+		var c *enrichment.ContractError
+		if !errors.As(err, &c) || len(c.Violations) != 2 {
+			t.Fatalf("%s: expected 2 violations, got %v", name, err)
+		}
+		if c.Violations[0] != "surname: required field is missing" || c.Violations[1] != "address: expected string, got integer" {
+			t.Fatalf("%s: unexpected violations %v", name, c.Violations)
+		}
+	}
+}
+
+func TestContractStatus(t *testing.T) {
+	failed, found := runContract(t, func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		})
+	})
+	if len(failed) != found {
+		t.Fatalf("expected found people to fail with 404, failed: %v", failed)
+	}
+}
+
+func TestDecodeInfoStrict(t *testing.T) {
+	data := []byte(`{"name":"Ivan","surname":"Ivanov","patronymic":"","address":"Moscow","birthDate":"1990-01-01"}`)
+
+	p, err := enrichment.DecodeInfo(data, false)
+	if err != nil || p.Name != "Ivan" {
+		t.Fatalf("expected unknown fields ignored, got %+v, %v", p, err)
+	}
+
+	_, err = enrichment.DecodeInfo(data, true)
+	if !errors.Is(err, service.ErrExternalAPI) {
+		t.Fatalf("expected unknown fields rejected in strict mode, got %v", err)
+	}
+
+	_, err = enrichment.DecodeInfo([]byte(`{"name":"Ivan","address":"Moscow"}`), false)
+	if !errors.Is(err, service.ErrExternalAPI) {
+		t.Fatalf("expected missing surname rejected, got %v", err)
+	}
+}
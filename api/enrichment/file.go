@@ -0,0 +1,169 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// fileRecord is one person in a JSON file, CSV files have the same columns
+// in a header row.
+type fileRecord struct {
+	PassportSerie  int    `json:"passportSerie"`
+	PassportNumber int    `json:"passportNumber"`
+	Name           string `json:"name"`
+	Patronymic     string `json:"patronymic"`
+	Surname        string `json:"surname"`
+	Address        string `json:"address"`
+}
+
+type passport struct {
+	serie, number int
+}
+
+// FileProvider looks people up in *.json and *.csv files. Files are read
+// once, on creation.
+type FileProvider struct {
+	people map[passport]Person
+}
+
+// NewFileProvider reads path, either a single file or a directory whose
+// *.json and *.csv files are read and other files are skipped.
+func NewFileProvider(path string) (*FileProvider, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		paths = paths[:0]
+		for _, entry := range entries {
+			if !entry.IsDir() && readers[strings.ToLower(filepath.Ext(entry.Name()))] != nil {
+				paths = append(paths, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	f := &FileProvider{people: map[passport]Person{}}
+	for _, p := range paths {
+		read := readers[strings.ToLower(filepath.Ext(p))]
+		if read == nil {
+			return nil, fmt.Errorf("%s: unsupported file, use *.json or *.csv", p)
+		}
+
+		records, err := read(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+
+		for _, r := range records {
+			f.people[passport{r.PassportSerie, r.PassportNumber}] = Person{
+				Name:       r.Name,
+				Patronymic: r.Patronymic,
+				Surname:    r.Surname,
+				Address:    r.Address,
+			}
+		}
+	}
+
+	log.WithFields(log.Fields{"path": path, "people": len(f.people)}).Info("Enrichment files loaded")
+	return f, nil
+}
+
+// readers parse files by extension.
+var readers = map[string]func(path string) ([]fileRecord, error){
+	".json": readJSON,
+	".csv":  readCSV,
+}
+
+func (f *FileProvider) Enrich(ctx context.Context, req Request) (Person, error) {
+	p, ok := f.people[passport{req.PassportSerie, req.PassportNumber}]
+	if !ok {
+		return Person{}, notFound(req.PassportSerie, req.PassportNumber)
+	}
+	return p, nil
+}
+
+// readJSON reads an array of records.
+func readJSON(path string) ([]fileRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []fileRecord
+	err = json.Unmarshal(data, &records)
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// readCSV reads records with a header row naming the columns, their order is free.
+func readCSV(path string) ([]fileRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"passportSerie", "passportNumber"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is missing", name)
+		}
+	}
+
+	get := func(row []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	records := make([]fileRecord, 0, len(rows)-1)
+	for n, row := range rows[1:] {
+		serie, err := strconv.Atoi(get(row, "passportSerie"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid passportSerie: %w", n+2, err)
+		}
+		number, err := strconv.Atoi(get(row, "passportNumber"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid passportNumber: %w", n+2, err)
+		}
+
+		records = append(records, fileRecord{
+			PassportSerie:  serie,
+			PassportNumber: number,
+			Name:           get(row, "name"),
+			Patronymic:     get(row, "patronymic"),
+			Surname:        get(row, "surname"),
+			Address:        get(row, "address"),
+		})
+	}
+	return records, nil
+}
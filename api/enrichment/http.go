@@ -0,0 +1,40 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time_tracker/api/external"
+)
+
+// HTTPProvider asks the people info service: GET /info?passportSerie=&passportNumber=.
+// Responses are checked against InfoSchema, Strict rejects ones with unknown fields.
+type HTTPProvider struct {
+	Client *external.Client
+	Strict bool
+}
+
+func NewHTTPProvider(client *external.Client, strict bool) *HTTPProvider {
+	return &HTTPProvider{Client: client, Strict: strict}
+}
+
+func (h *HTTPProvider) Enrich(ctx context.Context, req Request) (Person, error) {
+	query := url.Values{}
+	query.Set("passportSerie", fmt.Sprintf("%04d", req.PassportSerie))
+	query.Set("passportNumber", fmt.Sprintf("%06d", req.PassportNumber))
+
+	var raw json.RawMessage
+	err := h.Client.GetJSON(ctx, "/info", query, &raw)
+
+	var status *external.StatusError
+	if errors.As(err, &status) && status.StatusCode == http.StatusNotFound {
+		return Person{}, notFound(req.PassportSerie, req.PassportNumber)
+	}
+	if err != nil {
+		return Person{}, err
+	}
+	return DecodeInfo(raw, h.Strict)
+}
@@ -0,0 +1,59 @@
+package enrichment
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memorySweepSize is the number of entries after which Set drops expired ones.
+const memorySweepSize = 10000
+
+type memoryEntry struct {
+	person    Person
+	expiresAt time.Time
+}
+
+// MemoryCache keeps entries in the process memory, they are lost on restart.
+type MemoryCache struct {
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[passport]memoryEntry
+}
+
+func NewMemoryCache(ttl time.Duration) *MemoryCache {
+	return &MemoryCache{TTL: ttl, entries: map[passport]memoryEntry{}}
+}
+
+func (m *MemoryCache) Get(_ context.Context, serie, number int) (Person, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := passport{serie, number}
+	entry, ok := m.entries[key]
+	if !ok {
+		return Person{}, false, nil
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return Person{}, false, nil
+	}
+	return entry.person, true, nil
+}
+
+func (m *MemoryCache) Set(_ context.Context, serie, number int, p Person) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if len(m.entries) >= memorySweepSize {
+		for key, entry := range m.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(m.entries, key)
+			}
+		}
+	}
+	m.entries[passport{serie, number}] = memoryEntry{person: p, expiresAt: now.Add(m.TTL)}
+	return nil
+}
@@ -0,0 +1,10 @@
+package enrichment
+
+import "context"
+
+// NoopProvider takes personal data from the request body as it is.
+type NoopProvider struct{}
+
+func (NoopProvider) Enrich(ctx context.Context, req Request) (Person, error) {
+	return req.Submitted, nil
+}
@@ -0,0 +1,73 @@
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"time_tracker/api/external"
+	"time_tracker/api/service"
+	"time_tracker/api/validation"
+)
+
+const (
+	ProviderHTTP = "http"
+	ProviderFile = "file"
+	ProviderNone = "none"
+)
+
+// Person is personal data of a new user found by passport.
+type Person struct {
+	Name       string `json:"name"`
+	Patronymic string `json:"patronymic"`
+	Surname    string `json:"surname"`
+	Address    string `json:"address"`
+}
+
+// Request identifies the person by passport. Submitted holds the personal
+// data sent by the client, it is used by providers without a source of their
+// own. Fresh data is requested from the source bypassing caches.
+type Request struct {
+	PassportSerie  int
+	PassportNumber int
+	Submitted      Person
+	Fresh          bool
+}
+
+// Provider completes personal data of new users.
+type Provider interface {
+	Enrich(ctx context.Context, req Request) (Person, error)
+}
+
+// Config selects the provider: Client and Strict are used by http, Dir by file.
+type Config struct {
+	Provider string
+	Dir      string
+	Client   *external.Client
+	Strict   bool
+}
+
+// New returns the provider chosen by c.Provider, http by default.
+func New(c Config) (Provider, error) {
+	switch c.Provider {
+	case ProviderHTTP, "":
+		return NewHTTPProvider(c.Client, c.Strict), nil
+	case ProviderFile:
+		return NewFileProvider(c.Dir)
+	case ProviderNone:
+		return NoopProvider{}, nil
+	default:
+		return nil, fmt.Errorf("unknown enrichment provider %q, use %s, %s or %s",
+			c.Provider, ProviderHTTP, ProviderFile, ProviderNone)
+	}
+}
+
+func (p *Person) ValidateRequiredFields() error {
+	v := validation.New()
+	v.Required("name", p.Name)
+	v.Required("surname", p.Surname)
+	v.Required("address", p.Address)
+	return v.Err()
+}
+
+func notFound(serie, number int) error {
+	return fmt.Errorf("%w: no person with passport %04d %06d", service.ErrPersonNotFound, serie, number)
+}
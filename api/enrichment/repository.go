@@ -0,0 +1,68 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// CachedPerson is an entry of the enrichment_cache table.
+type CachedPerson struct {
+	ID             uint `gorm:"primarykey"`
+	PassportSerie  int  `gorm:"uniqueIndex:idx_enrichment_cache_passport"`
+	PassportNumber int  `gorm:"uniqueIndex:idx_enrichment_cache_passport"`
+	Name           string
+	Patronymic     string
+	Surname        string
+	Address        string
+	ExpiresAt      time.Time `gorm:"index"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (c *CachedPerson) TableName() string {
+	return "enrichment_cache"
+}
+
+// GormCache keeps entries in the database, they are shared by instances and
+// survive restarts. Expired entries are overwritten by the next Set.
+type GormCache struct {
+	db  *gorm.DB
+	TTL time.Duration
+}
+
+func NewGormCache(db *gorm.DB, ttl time.Duration) *GormCache {
+	return &GormCache{db: db, TTL: ttl}
+}
+
+func (g *GormCache) Get(ctx context.Context, serie, number int) (Person, bool, error) {
+	var c CachedPerson
+	err := g.db.WithContext(ctx).
+		Where("passport_serie = ? AND passport_number = ? AND expires_at > ?", serie, number, time.Now()).
+		First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Person{}, false, nil
+	}
+	if err != nil {
+		return Person{}, false, err
+	}
+	return Person{Name: c.Name, Patronymic: c.Patronymic, Surname: c.Surname, Address: c.Address}, true, nil
+}
+
+func (g *GormCache) Set(ctx context.Context, serie, number int, p Person) error {
+	c := CachedPerson{
+		PassportSerie:  serie,
+		PassportNumber: number,
+		Name:           p.Name,
+		Patronymic:     p.Patronymic,
+		Surname:        p.Surname,
+		Address:        p.Address,
+		ExpiresAt:      time.Now().Add(g.TTL),
+	}
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "passport_serie"}, {Name: "passport_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "patronymic", "surname", "address", "expires_at", "updated_at"}),
+	}).Create(&c).Error
+}
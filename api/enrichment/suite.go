@@ -0,0 +1,97 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time_tracker/api/external"
+)
+
+// ContractCase is a request of the contract suite and the status it must get.
+type ContractCase struct {
+	Name           string
+	PassportSerie  int
+	PassportNumber int
+	Status         int
+}
+
+// ContractResult is the outcome of a case, nil Err means it passed.
+type ContractResult struct {
+	Case ContractCase
+	Err  error
+}
+
+// ContractCases expects every person of people to be found and a passport
+// missing in people to be not found.
+func ContractCases(people *FileProvider) []ContractCase {
+	var cases []ContractCase
+	for p := range people.people {
+		cases = append(cases, ContractCase{
+			Name:           fmt.Sprintf("found %04d %06d", p.serie, p.number),
+			PassportSerie:  p.serie,
+			PassportNumber: p.number,
+			Status:         http.StatusOK,
+		})
+	}
+	sort.Slice(cases, func(i, j int) bool {
+		return cases[i].Name < cases[j].Name
+	})
+
+	missing := passport{0, 0}
+	for {
+		if _, ok := people.people[missing]; !ok {
+			break
+		}
+		missing.number++
+	}
+	return append(cases, ContractCase{
+		Name:           fmt.Sprintf("not found %04d %06d", missing.serie, missing.number),
+		PassportSerie:  missing.serie,
+		PassportNumber: missing.number,
+		Status:         http.StatusNotFound,
+	})
+}
+
+// RunContract requests the cases with client. Responses with 200 must
+// conform to InfoSchema strictly, without unknown fields.
+func RunContract(ctx context.Context, client *external.Client, cases []ContractCase) []ContractResult {
+	results := make([]ContractResult, 0, len(cases))
+	for _, c := range cases {
+		results = append(results, ContractResult{Case: c, Err: runCase(ctx, client, c)})
+	}
+	return results
+}
+
+func runCase(ctx context.Context, client *external.Client, c ContractCase) error {
+	query := url.Values{}
+	query.Set("passportSerie", fmt.Sprintf("%04d", c.PassportSerie))
+	query.Set("passportNumber", fmt.Sprintf("%06d", c.PassportNumber))
+
+	var raw json.RawMessage
+	err := client.GetJSON(ctx, "/info", query, &raw)
+
+	status := http.StatusOK
+	var statusErr *external.StatusError
+	if errors.As(err, &statusErr) {
+		status = statusErr.StatusCode
+	} else if err != nil {
+		return err
+	}
+
+	if status != c.Status {
+		return fmt.Errorf("expected status %d, got %d", c.Status, status)
+	}
+	if status != http.StatusOK {
+		return nil
+	}
+
+	// a nil *ContractError must not become a non-nil error
+	if c := CheckInfo(raw); c != nil {
+		return c
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+	"time_tracker/api/service"
+)
+
+// Config of the client. Zero Retries disables retries, zero BreakerThreshold
+// never opens the breaker.
+type Config struct {
+	BaseURL          string
+	Timeout          time.Duration
+	Retries          int
+	Backoff          time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+var ErrCircuitOpen = fmt.Errorf("%w: circuit breaker is open", service.ErrExternalUnavailable)
+
+// StatusError is returned when the external API responds with a status other than 200.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (s *StatusError) Error() string {
+	return s.Status
+}
+
+// Status describes the client for health checks.
+type Status struct {
+	URL      string
+	Breaker  string
+	Failures int
+}
+
+// Client calls an external JSON API with a timeout per attempt, retries with
+// exponential backoff and a circuit breaker shared by all calls.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+	Retries int
+	Backoff time.Duration
+	Breaker *Breaker
+}
+
+func New(c Config) *Client {
+	return &Client{
+		BaseURL: c.BaseURL,
+		HTTP:    &http.Client{Timeout: c.Timeout},
+		Retries: c.Retries,
+		Backoff: c.Backoff,
+		Breaker: NewBreaker(c.BreakerThreshold, c.BreakerCooldown),
+	}
+}
+
+// GetJSON requests BaseURL+path with query and decodes the JSON response into
+// v. Network errors, timeouts and 5xx responses are retried, while the
+// breaker is open calls fail at once with ErrCircuitOpen. 4xx responses are
+// returned as they are and don't count as failures of the service.
+func (c *Client) GetJSON(ctx context.Context, path string, query url.Values, v interface{}) error {
+	if !c.Breaker.Allow() {
+		return ErrCircuitOpen
+	}
+
+	err := c.getWithRetries(ctx, c.BaseURL+path+"?"+query.Encode(), v)
+
+	var status *StatusError
+	switch {
+	case err == nil, errors.As(err, &status) && status.StatusCode < http.StatusInternalServerError:
+		c.Breaker.Success()
+	case ctx.Err() != nil:
+		c.Breaker.Abort()
+	default:
+		c.Breaker.Failure()
+	}
+	return err
+}
+
+func (c *Client) Status() Status {
+	return Status{URL: c.BaseURL, Breaker: c.Breaker.State(), Failures: c.Breaker.Failures()}
+}
+
+func (c *Client) getWithRetries(ctx context.Context, link string, v interface{}) error {
+	for attempt := 0; ; attempt++ {
+		err := c.get(ctx, link, v)
+		if err == nil || !retryable(err) || attempt >= c.Retries || ctx.Err() != nil {
+			return err
+		}
+
+		delay := c.Backoff << attempt
+		log.WithFields(log.Fields{"link": link, "attempt": attempt + 1, "delay": delay}).
+			Warn("External API request failed, retrying: ", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context, link string, v interface{}) error {
+	log.WithField("Link", link).Debug("Requesting external API")
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.HTTP.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: response.StatusCode, Status: response.Status}
+	}
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	return service.DeserializeJSON(data, v)
+}
+
+// retryable reports whether the request may succeed if repeated: network
+// errors, timeouts and 5xx responses are, 4xx responses and bad JSON are not.
+func retryable(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.StatusCode >= http.StatusInternalServerError
+	}
+
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	return !errors.As(err, &syntax) && !errors.As(err, &typ)
+}
@@ -0,0 +1,212 @@
+package external_test
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"time_tracker/api/external"
+)
+
+// testUpstream answers with the statuses in order, the last one repeats, and
+// records when each request came.
+type testUpstream struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	delay    time.Duration
+	requests []time.Time
+}
+
+func newTestUpstream(t *testing.T, delay time.Duration, statuses ...int) *testUpstream {
+	t.Helper()
+	log.SetOutput(io.Discard)
+
+	u := &testUpstream{statuses: statuses, delay: delay}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.mu.Lock()
+		u.requests = append(u.requests, time.Now())
+		status := u.statuses[0]
+		if len(u.statuses) > 1 {
+			u.statuses = u.statuses[1:]
+		}
+		u.mu.Unlock()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(u.delay):
+		}
+
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			io.WriteString(w, `{"name":"Ivan"}`)
+		}
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *testUpstream) Requests() []time.Time {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]time.Time(nil), u.requests...)
+}
+
+func get(c *external.Client) error {
+	var v struct {
+		Name string `json:"name"`
+	}
+	return c.GetJSON(context.Background(), "/info", nil, &v)
+}
+
+func TestClientTimeout(t *testing.T) {
+	u := newTestUpstream(t, time.Second, http.StatusOK)
+	c := external.New(external.Config{BaseURL: u.URL, Timeout: 50 * time.Millisecond, Retries: 1, Backoff: time.Millisecond})
+
+	started := time.Now()
+	err := get(c)
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the timeout per attempt, took %v", elapsed)
+	}
+	if n := len(u.Requests()); n != 2 {
+		t.Fatalf("expected timed out request retried once, got %d requests", n)
+	}
+}
+
+func TestClientRetriesWithBackoff(t *testing.T) {
+	backoff := 20 * time.Millisecond
+	u := newTestUpstream(t, 0, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK)
+	c := external.New(external.Config{BaseURL: u.URL, Timeout: time.Second, Retries: 3, Backoff: backoff})
+
+	err := get(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := u.Requests()
+	if len(requests) != 4 {
+		t.Fatalf("expected 4 requests, got %d", len(requests))
+	}
+	for i := 1; i < len(requests); i++ {
+		delay := backoff << (i - 1)
+		if gap := requests[i].Sub(requests[i-1]); gap < delay {
+			t.Fatalf("expected retry %d after at least %v, got %v", i, delay, gap)
+		}
+	}
+	if c.Breaker.Failures() != 0 {
+		t.Fatalf("expected a successful call to reset failures, got %d", c.Breaker.Failures())
+	}
+}
+
+func TestClientGivesUpAfterRetries(t *testing.T) {
+	u := newTestUpstream(t, 0, http.StatusInternalServerError)
+	c := external.New(external.Config{BaseURL: u.URL, Timeout: time.Second, Retries: 2, Backoff: time.Millisecond})
+
+	err := get(c)
+
+	var status *external.StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %v", err)
+	}
+	if n := len(u.Requests()); n != 3 {
+		t.Fatalf("expected 3 requests, got %d", n)
+	}
+	if c.Breaker.Failures() != 1 {
+		t.Fatalf("expected one failed call, got %d", c.Breaker.Failures())
+	}
+}
+
+func TestClientDoesNotRetry4xx(t *testing.T) {
+	u := newTestUpstream(t, 0, http.StatusNotFound, http.StatusOK)
+	c := external.New(external.Config{BaseURL: u.URL, Timeout: time.Second, Retries: 3, Backoff: time.Millisecond,
+		BreakerThreshold: 1, BreakerCooldown: time.Hour})
+
+	err := get(c)
+
+	var status *external.StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %v", err)
+	}
+	if n := len(u.Requests()); n != 1 {
+		t.Fatalf("expected a single request, got %d", n)
+	}
+	if state := c.Breaker.State(); state != external.StateClosed {
+		t.Fatalf("expected 4xx not to open the breaker, got %s", state)
+	}
+}
+
+func TestClientBreaker(t *testing.T) {
+	cooldown := 50 * time.Millisecond
+	u := newTestUpstream(t, 0, http.StatusInternalServerError, http.StatusInternalServerError,
+		http.StatusInternalServerError, http.StatusOK)
+	c := external.New(external.Config{BaseURL: u.URL, Timeout: time.Second,
+		BreakerThreshold: 2, BreakerCooldown: cooldown})
+
+	for i := 0; i < 2; i++ {
+		err := get(c)
+		if err == nil {
+			t.Fatal("expected 500")
+		}
+	}
+	if state := c.Breaker.State(); state != external.StateOpen {
+		t.Fatalf("expected open breaker after the threshold, got %s", state)
+	}
+
+	err := get(c)
+	if !errors.Is(err, external.ErrCircuitOpen) || len(u.Requests()) != 2 {
+		t.Fatalf("expected the call rejected without a request, got %v after %d requests", err, len(u.Requests()))
+	}
+
+	// a failed trial call opens the breaker again
+	time.Sleep(cooldown)
+	if state := c.Breaker.State(); state != external.StateHalfOpen {
+		t.Fatalf("expected half open breaker after the cooldown, got %s", state)
+	}
+	err = get(c)
+	if err == nil || errors.Is(err, external.ErrCircuitOpen) {
+		t.Fatalf("expected the trial call made and failed, got %v", err)
+	}
+	if state := c.Breaker.State(); state != external.StateOpen {
+		t.Fatalf("expected open breaker after a failed trial, got %s", state)
+	}
+
+	// a successful one closes it
+	time.Sleep(cooldown)
+	err = get(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := c.Breaker.State(); state != external.StateClosed || c.Breaker.Failures() != 0 {
+		t.Fatalf("expected closed breaker, got %s with %d failures", state, c.Breaker.Failures())
+	}
+}
+
+func TestBreakerSingleTrial(t *testing.T) {
+	b := external.NewBreaker(1, 0)
+	b.Failure()
+
+	if !b.Allow() {
+		t.Fatal("expected the trial call allowed after the cooldown")
+	}
+	if b.Allow() {
+		t.Fatal("expected a single trial call at a time")
+	}
+
+	b.Abort()
+	if !b.Allow() {
+		t.Fatal("expected another trial after the aborted one")
+	}
+}
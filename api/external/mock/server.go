@@ -0,0 +1,133 @@
+// Package mock serves the people info API, GET /info?passportSerie=&passportNumber=,
+// from fixtures for local development and integration tests of the
+// external client.
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/service"
+)
+
+// Config sets up failures of the mock. Every response is delayed by Latency
+// plus a random part up to Jitter. ErrorRate of requests, from 0 to 1, and
+// every FailEvery-th one fail with ErrorStatus, 500 by default.
+type Config struct {
+	Latency     time.Duration
+	Jitter      time.Duration
+	ErrorRate   float64
+	FailEvery   int
+	ErrorStatus int
+}
+
+// Server answers with people found in the fixtures, 404 if the passport is
+// unknown and 400 if it's malformed.
+type Server struct {
+	People enrichment.Provider
+	Config Config
+
+	requests atomic.Int64
+}
+
+func New(people enrichment.Provider, c Config) *Server {
+	if c.ErrorStatus == 0 {
+		c.ErrorStatus = http.StatusInternalServerError
+	}
+	return &Server{People: people, Config: c}
+}
+
+// NewFromFixtures loads people from path, a *.json or *.csv file or a
+// directory of them in the format of the file enrichment provider.
+func NewFromFixtures(path string, c Config) (*Server, error) {
+	people, err := enrichment.NewFileProvider(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(people, c), nil
+}
+
+// Requests returns the number of /info requests served.
+func (s *Server) Requests() int64 {
+	return s.requests.Load()
+}
+
+func (s *Server) Handler() http.Handler {
+	router := http.NewServeMux()
+	router.HandleFunc("GET /info", s.InfoHandler)
+	return router
+}
+
+func (s *Server) InfoHandler(w http.ResponseWriter, r *http.Request) {
+	n := s.requests.Add(1)
+	logger := log.WithFields(log.Fields{"request": n, "query": r.URL.RawQuery})
+
+	err := s.delay(r.Context())
+	if err != nil {
+		return
+	}
+
+	if s.fails(n) {
+		logger.Info("Mock external API: injected error")
+		http.Error(w, http.StatusText(s.Config.ErrorStatus), s.Config.ErrorStatus)
+		return
+	}
+
+	serie, err1 := strconv.Atoi(r.URL.Query().Get("passportSerie"))
+	number, err2 := strconv.Atoi(r.URL.Query().Get("passportNumber"))
+	if err1 != nil || err2 != nil {
+		http.Error(w, "passportSerie and passportNumber must be numbers", http.StatusBadRequest)
+		return
+	}
+
+	p, err := s.People.Enrich(r.Context(), enrichment.Request{PassportSerie: serie, PassportNumber: number})
+	if errors.Is(err, service.ErrPersonNotFound) {
+		logger.Info("Mock external API: person not found")
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(p)
+	if err != nil {
+		logger.Error("Mock external API: ", err)
+		return
+	}
+	logger.Info("Mock external API: person found")
+}
+
+// delay waits for the configured latency or until the client gives up.
+func (s *Server) delay(ctx context.Context) error {
+	d := s.Config.Latency
+	if s.Config.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(s.Config.Jitter)))
+	}
+	if d <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func (s *Server) fails(n int64) bool {
+	if s.Config.FailEvery > 0 && n%int64(s.Config.FailEvery) == 0 {
+		return true
+	}
+	return s.Config.ErrorRate > 0 && rand.Float64() < s.Config.ErrorRate
+}
@@ -0,0 +1,143 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/external"
+	"time_tracker/api/external/mock"
+	"time_tracker/api/service"
+)
+
+const fixtures = "../../../fixtures/people.json"
+
+var (
+	found   = enrichment.Request{PassportSerie: 1234, PassportNumber: 567890}
+	missing = enrichment.Request{PassportSerie: 9999, PassportNumber: 999999}
+)
+
+// newProvider serves the fixtures with the mock and returns the http
+// enrichment provider requesting it through a client configured by c.
+func newProvider(t *testing.T, mc mock.Config, c external.Config) (*enrichment.HTTPProvider, *mock.Server) {
+	t.Helper()
+	log.SetOutput(io.Discard)
+
+	people, err := enrichment.NewFileProvider(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := mock.New(people, mc)
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+
+	c.BaseURL = ts.URL
+	return enrichment.NewHTTPProvider(external.New(c), true), server
+}
+
+func TestMockFound(t *testing.T) {
+	provider, server := newProvider(t, mock.Config{}, external.Config{Timeout: time.Second})
+
+	p, err := provider.Enrich(context.Background(), found)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Иван" || p.Surname != "Иванов" || p.Patronymic != "Иванович" || p.Address == "" {
+		t.Fatalf("expected the person of the fixtures, got %+v", p)
+	}
+
+	_, err = provider.Enrich(context.Background(), missing)
+	if !errors.Is(err, service.ErrPersonNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if n := server.Requests(); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+}
+
+func TestMockMalformedPassport(t *testing.T) {
+	log.SetOutput(io.Discard)
+	ts := httptest.NewServer(mock.New(enrichment.NoopProvider{}, mock.Config{}).Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/info?passportSerie=abcd&passportNumber=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestMockLatency(t *testing.T) {
+	provider, _ := newProvider(t, mock.Config{Latency: 30 * time.Millisecond, Jitter: 10 * time.Millisecond},
+		external.Config{Timeout: time.Second})
+
+	started := time.Now()
+	_, err := provider.Enrich(context.Background(), found)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed < 30*time.Millisecond {
+		t.Fatalf("expected the response delayed, took %v", elapsed)
+	}
+
+	provider, server := newProvider(t, mock.Config{Latency: time.Second},
+		external.Config{Timeout: 50 * time.Millisecond, Retries: 1, Backoff: time.Millisecond})
+
+	_, err = provider.Enrich(context.Background(), found)
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if n := server.Requests(); n != 2 {
+		t.Fatalf("expected timed out request retried once, got %d requests", n)
+	}
+}
+
+func TestMockFailEvery(t *testing.T) {
+	provider, server := newProvider(t, mock.Config{FailEvery: 2},
+		external.Config{Timeout: time.Second, Retries: 1, Backoff: time.Millisecond})
+
+	// requests 1 and 3 succeed, the 2nd fails and is retried
+	for i := 0; i < 2; i++ {
+		_, err := provider.Enrich(context.Background(), found)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := server.Requests(); n != 3 {
+		t.Fatalf("expected 3 requests, got %d", n)
+	}
+}
+
+func TestMockErrorRate(t *testing.T) {
+	provider, server := newProvider(t, mock.Config{ErrorRate: 1, ErrorStatus: http.StatusServiceUnavailable},
+		external.Config{Timeout: time.Second, Retries: 2, Backoff: time.Millisecond,
+			BreakerThreshold: 1, BreakerCooldown: time.Hour})
+
+	_, err := provider.Enrich(context.Background(), found)
+	var status *external.StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %v", err)
+	}
+	if n := server.Requests(); n != 3 {
+		t.Fatalf("expected the request retried twice, got %d requests", n)
+	}
+
+	_, err = provider.Enrich(context.Background(), found)
+	if !errors.Is(err, external.ErrCircuitOpen) {
+		t.Fatalf("expected open breaker, got %v", err)
+	}
+	if n := server.Requests(); n != 3 {
+		t.Fatalf("expected no request while the breaker is open, got %d requests", n)
+	}
+}
@@ -0,0 +1,129 @@
+package health
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"net/http"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/external"
+	"time_tracker/api/service"
+	"time_tracker/api/user"
+)
+
+// Handler reports whether the service and its dependencies are usable.
+// External and Cache are nil when they aren't used.
+type Handler struct {
+	DB       *gorm.DB
+	Users    user.UserRepository
+	External *external.Client
+	Cache    *enrichment.CachingProvider
+}
+
+func NewHandler(d *gorm.DB, users user.UserRepository, ext *external.Client, cache *enrichment.CachingProvider) *Handler {
+	return &Handler{DB: d, Users: users, External: ext, Cache: cache}
+}
+
+// HealthHandler godoc
+//
+//	@Summary		Health check
+//	@Description	Overall status of the service for load balancers and uptime checks, details are available to admins on /health/details.
+//	@Description	Status is "degraded" while the circuit breaker of the external API isn't closed, new users can't be created then. 503 is returned if the database is down.
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200	{object}	service.OkResponse{data=Summary}
+//	@Failure		503	{object}	service.OkResponse{data=Summary}
+//	@Router			/health [get]
+func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	log.Debug(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	report, code := h.check(r)
+
+	service.ServerResponse(w, service.OkResponse{
+		Code:    code,
+		Message: "Health status: " + report.Status,
+		Data:    Summary{Status: report.Status},
+	})
+}
+
+// HealthDetailsHandler godoc
+//
+//	@Summary		Health details
+//	@Description	Check the database connection and the circuit breaker of the external API, if the http enrichment provider is used.
+//	@Description	Hits and misses of the enrichment cache are reported if the cache is enabled. Available to admins only.
+//	@Tags			Health
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	service.OkResponse{data=Report}
+//	@Failure		401	{object}	service.ErrorResponse
+//	@Failure		403	{object}	service.ErrorResponse
+//	@Failure		503	{object}	service.OkResponse{data=Report}
+//	@Router			/health/details [get]
+func (h *Handler) HealthDetailsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	if !actor.IsAdmin() {
+		e.Error403(service.ErrForbidden)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	report, code := h.check(r)
+
+	service.ServerResponse(w, service.OkResponse{
+		Code:    code,
+		Message: "Health status: " + report.Status,
+		Data:    report,
+	})
+}
+
+// check builds the report and the status code to respond with.
+func (h *Handler) check(r *http.Request) (Report, int) {
+	report := Report{Status: StatusOk, Database: Check{Status: "up"}}
+	code := http.StatusOK
+
+	// External is nil when users are enriched without the external API
+	if h.External != nil {
+		ext := h.External.Status()
+		report.ExternalAPI = &ExternalCheck{Status: "up", URL: ext.URL, Breaker: ext.Breaker, Failures: ext.Failures}
+
+		if ext.Breaker != external.StateClosed {
+			report.Status = StatusDegraded
+			report.ExternalAPI.Status = "down"
+			report.ExternalAPI.Error = "circuit breaker is " + ext.Breaker
+		}
+	}
+
+	if h.Cache != nil {
+		stats := h.Cache.Stats()
+		report.Cache = &stats
+	}
+
+	err := h.pingDB(r)
+	if err != nil {
+		report.Status = StatusDown
+		report.Database = Check{Status: "down", Error: err.Error()}
+		code = http.StatusServiceUnavailable
+		log.Error("Health check: database is down: ", err)
+	}
+	return report, code
+}
+
+func (h *Handler) pingDB(r *http.Request) error {
+	sqlDB, err := h.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(r.Context())
+}
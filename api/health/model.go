@@ -0,0 +1,34 @@
+package health
+
+import "time_tracker/api/enrichment"
+
+const (
+	StatusOk       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// Summary is the public part of Report.
+type Summary struct {
+	Status string `json:"status" example:"ok" enums:"ok,degraded,down" extensions:"x-order=1"`
+}
+
+type Check struct {
+	Status string `json:"status" example:"up" enums:"up,down" extensions:"x-order=1"`
+	Error  string `json:"error,omitempty" extensions:"x-order=2"`
+}
+
+type ExternalCheck struct {
+	Status   string `json:"status" example:"up" enums:"up,down" extensions:"x-order=1"`
+	Error    string `json:"error,omitempty" extensions:"x-order=2"`
+	URL      string `json:"url" extensions:"x-order=3"`
+	Breaker  string `json:"breaker" example:"closed" enums:"closed,open,half_open" extensions:"x-order=4"`
+	Failures int    `json:"failures" extensions:"x-order=5"`
+}
+
+type Report struct {
+	Status      string                 `json:"status" example:"ok" enums:"ok,degraded,down" extensions:"x-order=1"`
+	Database    Check                  `json:"database" extensions:"x-order=2"`
+	ExternalAPI *ExternalCheck         `json:"externalApi,omitempty" extensions:"x-order=3"`
+	Cache       *enrichment.CacheStats `json:"enrichmentCache,omitempty" extensions:"x-order=4"`
+}
@@ -0,0 +1,8 @@
+package health
+
+import "net/http"
+
+func (h *Handler) AddRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /api/v1/health", h.HealthHandler)
+	router.HandleFunc("GET /api/v1/health/details", h.HealthDetailsHandler)
+}
@@ -1,111 +1,212 @@
 package service
 
 import (
+	"errors"
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 	"net/http"
+	"strings"
 )
 
+const problemTypePrefix = "urn:time-tracker:problem:"
+
+// Error is a sentinel error with HTTP status and stable machine-readable code.
+// Wrap it to add details: fmt.Errorf("%w: ...", service.ErrValidation).
+type Error struct {
+	Status int
+	Code   string
+	Title  string
+}
+
+func (e *Error) Error() string {
+	return e.Title
+}
+
+var (
+	ErrBadRequest          = &Error{http.StatusBadRequest, "request.bad", "Bad request"}
+	ErrInvalidId           = &Error{http.StatusBadRequest, "request.invalid_id", "ID read error"}
+	ErrReadBody            = &Error{http.StatusBadRequest, "request.read_body", "Read body error"}
+	ErrBodyTooLarge        = &Error{http.StatusRequestEntityTooLarge, "request.body_too_large", "Request body is too large"}
+	ErrMalformedJSON       = &Error{http.StatusBadRequest, "request.malformed_json", "Deserialize error"}
+	ErrValidation          = &Error{http.StatusBadRequest, "validation.failed", "Validation error"}
+	ErrUnauthorized        = &Error{http.StatusUnauthorized, "auth.unauthorized", "Unauthorized"}
+	ErrForbidden           = &Error{http.StatusForbidden, "auth.forbidden", "Forbidden"}
+	ErrNotFound            = &Error{http.StatusNotFound, "not_found", "Not found"}
+	ErrInternal            = &Error{http.StatusInternalServerError, "internal", "Internal Server Error"}
+	ErrSerialize           = &Error{http.StatusInternalServerError, "response.serialize", "Serialize error"}
+	ErrDatabase            = &Error{http.StatusInternalServerError, "database", "Database error"}
+	ErrExternalAPI         = &Error{http.StatusBadGateway, "external_api.error", "External API Error"}
+	ErrExternalUnavailable = &Error{http.StatusServiceUnavailable, "external_api.unavailable", "External API is unavailable"}
+	ErrConflict            = &Error{http.StatusConflict, "conflict", "Conflict with existing data"}
+	ErrPreconditionFailed  = &Error{http.StatusPreconditionFailed, "precondition_failed", "Resource was modified"}
+	ErrPassportExists      = &Error{http.StatusConflict, "user.passport_exists", "Passport credentials already exist"}
+	ErrLoginExists         = &Error{http.StatusConflict, "user.login_exists", "Login already taken"}
+	ErrUserHasTasks        = &Error{http.StatusConflict, "user.has_tasks", "User has tasks"}
+	ErrLastAdmin           = &Error{http.StatusConflict, "user.last_admin", "Organization must keep at least one admin"}
+	ErrPersonNotFound      = &Error{http.StatusNotFound, "user.person_not_found", "Person with the passport not found"}
+	ErrTaskOwnerNotFound   = &Error{http.StatusNotFound, "task.owner_not_found", "Task owner not found"}
+	ErrTaskNotStarted      = &Error{http.StatusConflict, "task.not_started", "Task not started"}
+	ErrTaskAlreadyStarted  = &Error{http.StatusConflict, "task.already_started", "Task is already started"}
+	ErrTaskAlreadyFinished = &Error{http.StatusConflict, "task.already_finished", "Task is already finished"}
+)
+
+// FieldError describes a problem with a single field of the request.
+// Path is a JSON pointer to the field, Rule names the failed check.
+type FieldError struct {
+	Field   string `json:"field" example:"passportNumber" extensions:"x-order=1"`
+	Path    string `json:"path" example:"/passportNumber" extensions:"x-order=2"`
+	Rule    string `json:"rule" example:"length" extensions:"x-order=3"`
+	Message string `json:"message" example:"passport number must be 6 characters long" extensions:"x-order=4"`
+}
+
+func (f *FieldError) Error() string {
+	return f.Message
+}
+
+// FieldErrors is returned when several fields are invalid at once.
+type FieldErrors []FieldError
+
+func (f FieldErrors) Error() string {
+	msgs := make([]string, len(f))
+	for i, fe := range f {
+		msgs[i] = fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ErrorResponse is an RFC 7807 problem details body, sent as application/problem+json.
 type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Type   string       `json:"type" example:"urn:time-tracker:problem:not_found" extensions:"x-order=1"`
+	Title  string       `json:"title" example:"Not found" extensions:"x-order=2"`
+	Status int          `json:"status" example:"404" extensions:"x-order=3"`
+	Code   string       `json:"code" example:"not_found" extensions:"x-order=4"`
+	Detail string       `json:"detail,omitempty" extensions:"x-order=5"`
+	Errors []FieldError `json:"errors,omitempty" extensions:"x-order=6"`
+}
+
+func (e *ErrorResponse) set(sentinel *Error, err error) {
+	e.Type = problemTypePrefix + sentinel.Code
+	e.Title = sentinel.Title
+	e.Status = sentinel.Status
+	e.Code = sentinel.Code
+	e.Detail = ""
+	e.Errors = nil
+
+	if err == nil {
+		log.Error(sentinel.Title)
+		return
+	}
+
+	if err != error(sentinel) {
+		e.Detail = err.Error()
+	}
+
+	var fe *FieldError
+	var fes FieldErrors
+	if errors.As(err, &fe) {
+		e.Errors = []FieldError{*fe}
+	} else if errors.As(err, &fes) {
+		e.Errors = fes
+	}
+	log.Error(err)
+}
+
+// FromError fills e from the sentinel wrapped by err. Unknown errors become
+// database or internal errors.
+func (e *ErrorResponse) FromError(err error) {
+	var sentinel *Error
+	switch {
+	case errors.As(err, &sentinel):
+		e.set(sentinel, err)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		e.set(ErrNotFound, nil)
+	case errors.As(err, new(*FieldError)), errors.As(err, new(FieldErrors)):
+		e.set(ErrValidation, err)
+	default:
+		e.set(ErrDatabase, err)
+	}
 }
 
 func (e *ErrorResponse) Error400(err error) {
-	e.Code = http.StatusBadRequest
-	e.Message = "Bad Request: " + err.Error()
-	log.Error(err)
+	e.set(ErrBadRequest, err)
+}
+
+func (e *ErrorResponse) Error401(err error) {
+	e.set(ErrUnauthorized, err)
+}
+
+func (e *ErrorResponse) Error403(err error) {
+	e.set(ErrForbidden, err)
 }
 
 func (e *ErrorResponse) Error404() {
-	msg := "Not found"
-	e.Code = http.StatusNotFound
-	e.Message = msg
-	log.Error(msg)
+	e.set(ErrNotFound, nil)
 }
 
 func (e *ErrorResponse) Error500(err error) {
-	e.Code = http.StatusInternalServerError
-	e.Message = "Internal Server Error: " + err.Error()
-	log.Error(err)
+	e.set(ErrInternal, err)
 }
 
 func (e *ErrorResponse) UuidParseError(err error) {
-	e.Code = http.StatusBadRequest
-	e.Message = "ID read error: " + err.Error()
-	log.Error(err)
+	e.set(ErrInvalidId, err)
 }
 
+// ReadBodyError reports bodies cut by http.MaxBytesReader as too large.
 func (e *ErrorResponse) ReadBodyError(err error) {
-	e.Code = http.StatusBadRequest
-	e.Message = "Read body error: " + err.Error()
-	log.Error(err)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		e.set(ErrBodyTooLarge, err)
+		return
+	}
+	e.set(ErrReadBody, err)
 }
 
 func (e *ErrorResponse) DeserializeError(err error) {
-	e.Code = http.StatusInternalServerError
-	e.Message = "Deserialize error: " + err.Error()
-	log.Error(err)
+	e.set(ErrMalformedJSON, err)
 }
 
 func (e *ErrorResponse) SerializeError(err error) {
-	e.Code = http.StatusInternalServerError
-	e.Message = "Serialize error: " + err.Error()
-	log.Error(err)
+	e.set(ErrSerialize, err)
 }
 
 func (e *ErrorResponse) ValidationError(err error) {
-	e.Code = http.StatusBadRequest
-	e.Message = "Validation error: " + err.Error()
-	log.Error(err)
+	e.set(ErrValidation, err)
 }
 
 func (e *ErrorResponse) DBError(err error) {
-	e.Code = http.StatusInternalServerError
-	e.Message = "Database error: " + err.Error()
-	log.Error(err)
+	e.set(ErrDatabase, err)
 }
 
-func (e *ErrorResponse) DBExists() {
-	msg := "Already exists"
-	e.Code = http.StatusBadRequest
-	e.Message = msg
-	log.Error(msg)
+func (e *ErrorResponse) DBPassportExists() {
+	e.set(ErrPassportExists, nil)
 }
 
-func (e *ErrorResponse) DBPassportExists() {
-	msg := "Passport credentials already exist"
-	e.Code = http.StatusBadRequest
-	e.Message = msg
-	log.Error(msg)
+func (e *ErrorResponse) DBLoginExists() {
+	e.set(ErrLoginExists, nil)
 }
 
 func (e *ErrorResponse) DBTaskOwnerNotFound() {
-	msg := "Task owner not found"
-	e.Code = http.StatusNotFound
-	e.Message = msg
-	log.Error(msg)
+	e.set(ErrTaskOwnerNotFound, nil)
 }
 
 func (e *ErrorResponse) TaskNotStartedError() {
-	e.Code = http.StatusBadRequest
-	e.Message = "Task not started."
-	log.Error("Task not started.")
+	e.set(ErrTaskNotStarted, nil)
 }
 
 func (e *ErrorResponse) TaskIsAlreadyStartedError() {
-	e.Code = http.StatusBadRequest
-	e.Message = "Task is already started."
-	log.Error("Task is already started.")
+	e.set(ErrTaskAlreadyStarted, nil)
 }
 
 func (e *ErrorResponse) TaskIsAlreadyFinishedError() {
-	e.Code = http.StatusBadRequest
-	e.Message = "Task is already finished."
-	log.Error("Task is already finished.")
+	e.set(ErrTaskAlreadyFinished, nil)
 }
 
+// ExternalAPIError keeps the sentinel wrapped by err, other errors become ErrExternalAPI.
 func (e *ErrorResponse) ExternalAPIError(err error) {
-	e.Code = http.StatusInternalServerError
-	e.Message = "External API Error: " + err.Error()
-	log.Error(err)
+	var sentinel *Error
+	if errors.As(err, &sentinel) {
+		e.set(sentinel, err)
+		return
+	}
+	e.set(ErrExternalAPI, err)
 }
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETag formats the version of a resource as a strong entity tag.
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// IfMatch checks the If-Match header against the current version of the
+// resource. It returns the version the write must be conditioned on, 0 when
+// the header is absent, and ErrPreconditionFailed when no tag matches.
+// Weak tags never match, as RFC 9110 requires strong comparison here.
+func IfMatch(r *http.Request, version int64) (int64, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, nil
+	}
+
+	etag := ETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return version, nil
+		}
+	}
+	return 0, ErrPreconditionFailed
+}
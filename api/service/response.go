@@ -5,16 +5,44 @@ import (
 	"net/http"
 )
 
+// ServerResponse writes dataInterface as JSON. Status of ErrorResponse and
+// Code of OkResponse are used as HTTP status, 204 is sent without a body.
+// ErrorResponse is sent as application/problem+json.
 func ServerResponse(w http.ResponseWriter, dataInterface interface{}) {
+	status := http.StatusOK
+	switch v := dataInterface.(type) {
+	case ErrorResponse:
+		status = v.Status
+		w.Header().Set("Content-Type", "application/problem+json")
+	case *ErrorResponse:
+		status = v.Status
+		w.Header().Set("Content-Type", "application/problem+json")
+	case OkResponse:
+		status = v.Code
+	case *OkResponse:
+		status = v.Code
+	}
+
+	if status < 100 || status > 599 {
+		status = http.StatusOK
+	}
+
+	if status == http.StatusNoContent {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(status)
+		return
+	}
+
 	bytes, err := SerializeJSON(dataInterface)
 	if err != nil {
 		log.WithField("Serialize error", err).Error()
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
+	w.WriteHeader(status)
 	_, err = w.Write(bytes)
 	if err != nil {
 		log.WithField("Response error", err).Error()
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
@@ -0,0 +1,180 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"time_tracker/api/service"
+	"time_tracker/api/user"
+	"time_tracker/api/validation"
+)
+
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+	batchMaxOperations  = 100
+)
+
+var errBatchAborted = errors.New("batch aborted")
+
+// Execute runs all operations inside one transaction. Every operation gets
+// its own savepoint, so in best_effort mode a failed operation is rolled back
+// alone, while in atomic mode the first failure rolls back the whole batch.
+// Created tasks belong to actor, other operations require access to the task.
+func (b *BatchRequest) Execute(tasks TaskRepository, actor *user.FullUser) (BatchResult, error) {
+	result := BatchResult{
+		Mode:    b.Mode,
+		Results: make([]BatchOperationResult, len(b.Operations)),
+	}
+
+	failedAt := -1
+	err := tasks.Transaction(func(tx TaskRepository) error {
+		for i := range b.Operations {
+			op := b.Operations[i]
+			res := &result.Results[i]
+			res.Index = i
+			res.Op = op.Op
+
+			err := tx.Transaction(func(sp TaskRepository) error {
+				return op.apply(sp, actor, res)
+			})
+			if err != nil && b.Mode == BatchModeAtomic {
+				failedAt = i
+				return errBatchAborted
+			}
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errBatchAborted) {
+		return result, err
+	}
+
+	result.Committed = err == nil
+	for i := range result.Results {
+		res := &result.Results[i]
+		if failedAt >= 0 && i != failedAt {
+			res.Index = i
+			res.Op = b.Operations[i].Op
+			res.Status = http.StatusFailedDependency
+			res.Code = "batch.aborted"
+			if i < failedAt {
+				res.Message = "Rolled back: batch aborted"
+			} else {
+				res.Message = "Skipped: batch aborted"
+			}
+		}
+
+		if res.Status == http.StatusOK {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+
+	return result, nil
+}
+
+// apply executes a single operation and records its outcome in res.
+// A non-nil error makes the caller roll back to the operation's savepoint.
+func (o *BatchOperation) apply(tasks TaskRepository, actor *user.FullUser, res *BatchOperationResult) error {
+	var e service.ErrorResponse
+	var err error
+	var msg string
+
+	res.TaskId = o.TaskId
+
+	var tsk FullTask
+	if o.Op != "create" {
+		tsk, err = authorizeTask(tasks, actor, o.TaskId)
+		if err != nil {
+			e.FromError(err)
+			res.setError(&e)
+			return err
+		}
+	}
+
+	switch o.Op {
+	case "create":
+		tsk = FullTask{
+			TaskId:         uuid.New(),
+			OwnerId:        actor.UserId,
+			Title:          o.Title,
+			Content:        o.Content,
+			OrganizationId: actor.OrganizationId,
+		}
+		res.TaskId = tsk.TaskId
+		err = tsk.validateNewTask()
+		if err != nil {
+			e.ValidationError(err)
+			break
+		}
+		err = tasks.Create(&tsk)
+		if err != nil {
+			e.FromError(err)
+		}
+		msg = "Task created successfully"
+
+	case "update":
+		upd := UpdateTask{TaskId: o.TaskId, Title: o.Title, Content: o.Content}
+		err = upd.validateOnUpdate()
+		if err != nil {
+			e.ValidationError(err)
+			break
+		}
+		err = tasks.UpdatePart(&upd)
+		if err != nil {
+			e.FromError(err)
+		}
+		msg = "Task updated successfully"
+
+	case "delete":
+		err = tasks.Delete(o.TaskId, 0)
+		if err != nil {
+			e.FromError(err)
+		}
+		msg = "Task deleted successfully"
+
+	case "start":
+		err = startTask(tasks, &tsk)
+		if err != nil {
+			e.FromError(err)
+		}
+		msg = "Task started successfully"
+
+	case "finish":
+		err = finishTask(tasks, &tsk)
+		if err != nil {
+			e.FromError(err)
+		}
+		msg = "Task finished successfully"
+
+	default:
+		err = &service.FieldError{
+			Field:   "op",
+			Path:    fmt.Sprintf("/operations/%d/op", res.Index),
+			Rule:    validation.RuleOneOf,
+			Message: fmt.Sprintf("unknown operation %q", o.Op),
+		}
+		e.ValidationError(err)
+	}
+
+	if err != nil {
+		res.setError(&e)
+		return err
+	}
+
+	res.Status = http.StatusOK
+	res.Message = msg
+	return nil
+}
+
+func (r *BatchOperationResult) setError(e *service.ErrorResponse) {
+	r.Status = e.Status
+	r.Code = e.Code
+	r.Message = e.Title
+	if e.Detail != "" {
+		r.Message = e.Detail
+	}
+}
@@ -0,0 +1,135 @@
+package task_test
+
+import (
+	"github.com/google/uuid"
+	"net/http"
+	"testing"
+	"time_tracker/api/apitest"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/task"
+	"time_tracker/api/user"
+)
+
+// batchBackends return the task repository, the actor and a task it owns,
+// savepoints of MemoryRepository are implemented by hand with snapshots.
+var batchBackends = []struct {
+	name  string
+	setup func(t *testing.T) (task.TaskRepository, user.FullUser, task.FullTask)
+}{
+	{"gorm", func(t *testing.T) (task.TaskRepository, user.FullUser, task.FullTask) {
+		DB := apitest.NewDB(t)
+		_, owner := apitest.CreateOrganization(t, task.NewGormStore(DB), enrichment.NoopProvider{})
+		return withTask(t, task.NewGormRepository(DB), owner)
+	}},
+	{"memory", func(t *testing.T) (task.TaskRepository, user.FullUser, task.FullTask) {
+		tasks := task.NewMemoryRepository()
+		_, owner := apitest.CreateOrganization(t, task.NewMemoryStore(user.NewMemoryRepository(), tasks), enrichment.NoopProvider{})
+		return withTask(t, tasks, owner)
+	}},
+}
+
+func withTask(t *testing.T, tasks task.TaskRepository, owner user.FullUser) (task.TaskRepository, user.FullUser, task.FullTask) {
+	t.Helper()
+
+	tsk := task.FullTask{TaskId: uuid.New(), OwnerId: owner.UserId, OrganizationId: owner.OrganizationId, Title: "Report"}
+	err := tasks.Create(&tsk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tasks, owner, tsk
+}
+
+// expectResults compares statuses and problem codes of the operations.
+func expectResults(t *testing.T, result task.BatchResult, want ...string) {
+	t.Helper()
+
+	if len(result.Results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(result.Results))
+	}
+	for i, res := range result.Results {
+		got := http.StatusText(res.Status)
+		if res.Code != "" {
+			got = res.Code
+		}
+		if got != want[i] {
+			t.Fatalf("operation %d: expected %s, got %d %s: %s", i, want[i], res.Status, res.Code, res.Message)
+		}
+	}
+}
+
+func TestBatchAtomicRollback(t *testing.T) {
+	apitest.Setup()
+
+	for _, backend := range batchBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			tasks, owner, tsk := backend.setup(t)
+
+			batch := task.BatchRequest{Mode: task.BatchModeAtomic, Operations: []task.BatchOperation{
+				{Op: "create", Title: "Created"},
+				{Op: "start", TaskId: tsk.TaskId},
+				{Op: "finish", TaskId: uuid.New()},
+				{Op: "create", Title: "Skipped"},
+			}}
+			result, err := batch.Execute(tasks, &owner)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Committed || result.Succeeded != 0 || result.Failed != 4 {
+				t.Fatalf("expected nothing committed, got %+v", result)
+			}
+			expectResults(t, result, "batch.aborted", "batch.aborted", "not_found", "batch.aborted")
+
+			count, err := tasks.CountByOwner(owner.UserId)
+			if err != nil || count != 1 {
+				t.Fatalf("expected the created task rolled back, got %d tasks, %v", count, err)
+			}
+			stored, err := tasks.ReadOne(owner.OrganizationId, tsk.TaskId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !stored.StartAt.IsZero() || stored.Version != tsk.Version {
+				t.Fatalf("expected the start rolled back, got start %v and version %d", stored.StartAt, stored.Version)
+			}
+		})
+	}
+}
+
+func TestBatchBestEffort(t *testing.T) {
+	apitest.Setup()
+
+	for _, backend := range batchBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			tasks, owner, tsk := backend.setup(t)
+
+			batch := task.BatchRequest{Mode: task.BatchModeBestEffort, Operations: []task.BatchOperation{
+				{Op: "create", Title: "Created"},
+				{Op: "start", TaskId: tsk.TaskId},
+				{Op: "start", TaskId: tsk.TaskId},
+				{Op: "finish", TaskId: uuid.New()},
+				{Op: "finish", TaskId: tsk.TaskId},
+			}}
+			result, err := batch.Execute(tasks, &owner)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !result.Committed || result.Succeeded != 3 || result.Failed != 2 {
+				t.Fatalf("expected 3 operations committed, got %+v", result)
+			}
+			expectResults(t, result, "OK", "OK", "task.already_started", "not_found", "OK")
+
+			count, err := tasks.CountByOwner(owner.UserId)
+			if err != nil || count != 2 {
+				t.Fatalf("expected the created task kept, got %d tasks, %v", count, err)
+			}
+			stored, err := tasks.ReadOne(owner.OrganizationId, tsk.TaskId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.StartAt.IsZero() || stored.FinishAt.IsZero() {
+				t.Fatalf("expected the task started and finished, got %v and %v", stored.StartAt, stored.FinishAt)
+			}
+		})
+	}
+}
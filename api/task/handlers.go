@@ -12,24 +12,45 @@ import (
 	"time_tracker/api/user"
 )
 
+// Handler serves task endpoints, users are needed to resolve the actor and report owners.
+type Handler struct {
+	Tasks TaskRepository
+	Users user.UserRepository
+}
+
+func NewHandler(tasks TaskRepository, users user.UserRepository) *Handler {
+	return &Handler{Tasks: tasks, Users: users}
+}
+
 // CreateTaskHandler godoc
 //
 //	@Summary		Create task
-//	@Description	Create task for user
+//	@Description	Create task for the authenticated user
 //	@Tags			Task
 //	@Accept			json
 //	@Produce		json
-//	@Param			New	task		body	CreateTask	true	"Owner UUID and title are required"
-//	@Success		200	{object}	FullTask
+//	@Security		BearerAuth
+//	@Param			New	task		body	CreateTask	true	"Title is required"
+//	@Success		201	{object}	FullTask
 //	@Failure		400	{object}	service.ErrorResponse
+//	@Failure		401	{object}	service.ErrorResponse
+//	@Failure		403	{object}	service.ErrorResponse
+//	@Failure		413	{object}	service.ErrorResponse
 //	@Failure		500	{object}	service.ErrorResponse
 //	@Router			/task [post]
-func CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
 	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
 
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
 	data, err := io.ReadAll(r.Body)
 	if err != nil {
 		e.ReadBodyError(err)
@@ -38,29 +59,32 @@ func CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	tsk := FullTask{TaskId: uuid.New()}
-
-	err = service.DeserializeJSON(data, &tsk)
+	var newTsk CreateTask
+	err = service.DeserializeJSON(data, &newTsk)
 	if err != nil {
-		e.ReadBodyError(err)
+		e.DeserializeError(err)
 		service.ServerResponse(w, e)
 		return
 	}
 
+	tsk := FullTask{
+		TaskId:         uuid.New(),
+		OwnerId:        actor.UserId,
+		Title:          newTsk.Title,
+		Content:        newTsk.Content,
+		OrganizationId: actor.OrganizationId,
+	}
+
 	err = tsk.validateNewTask()
 	if err != nil {
-		if err.Error() == "record not found" {
-			e.DBTaskOwnerNotFound()
-		} else {
-			e.ValidationError(err)
-		}
+		e.ValidationError(err)
 		service.ServerResponse(w, e)
 		return
 	}
 
-	err = tsk.Create()
+	err = h.Tasks.Create(&tsk)
 	if err != nil {
-		e.DBError(err)
+		e.FromError(err)
 		service.ServerResponse(w, e)
 		return
 	}
@@ -68,7 +92,7 @@ func CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	msg := "Task created successfully"
 
 	service.ServerResponse(w, service.OkResponse{
-		Code:    http.StatusOK,
+		Code:    http.StatusCreated,
 		Message: msg,
 		Data:    tsk.TaskId,
 	})
@@ -78,21 +102,32 @@ func CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
 // ReadOneTaskHandler godoc
 //
 //	@Summary		Get task
-//	@Description	Get task by task UUID
+//	@Description	Get task by task UUID. ETag header holds the task version for If-Match on update and delete.
 //	@Tags			Task
 //	@Produce		json
+//	@Security		BearerAuth
 //	@Param			uuid	path		string	true	"Provide task's uuid"
 //	@Success		200		{object}	FullTask
+//	@Header			200		{string}	ETag	"Task version"
 //	@Failure		400		{object}	service.ErrorResponse
+//	@Failure		401		{object}	service.ErrorResponse
+//	@Failure		403		{object}	service.ErrorResponse
 //	@Failure		404		{object}	service.ErrorResponse
 //	@Failure		500		{object}	service.ErrorResponse
 //	@Router			/task/{uuid} [get]
-func ReadOneTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) ReadOneTaskHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
 	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
 
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
 	taskId, err := uuid.Parse(r.PathValue("uuid"))
 	if err != nil {
 		e.UuidParseError(err)
@@ -100,18 +135,14 @@ func ReadOneTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tsk := FullTask{TaskId: taskId}
-	err = tsk.ReadOne()
+	tsk, err := authorizeTask(h.Tasks, &actor, taskId)
 	if err != nil {
-		if err.Error() == "record not found" {
-			e.Error404()
-		} else {
-			e.DBError(err)
-		}
+		e.FromError(err)
 		service.ServerResponse(w, e)
 		return
 	}
 
+	w.Header().Set("ETag", service.ETag(tsk.Version))
 	service.ServerResponse(w, tsk)
 	log.Info("Read one successfully")
 }
@@ -122,18 +153,28 @@ func ReadOneTaskHandler(w http.ResponseWriter, r *http.Request) {
 //	@Description	Get all tasks for user
 //	@Tags			Task
 //	@Produce		json
+//	@Security		BearerAuth
 //	@Param			user_uuid	path		string	true	"Provide user's uuid"
 //	@Success		200			{object}	FullTask
 //	@Failure		400			{object}	service.ErrorResponse
+//	@Failure		401			{object}	service.ErrorResponse
+//	@Failure		403			{object}	service.ErrorResponse
 //	@Failure		404			{object}	service.ErrorResponse
 //	@Failure		500			{object}	service.ErrorResponse
 //	@Router			/tasks/{user_uuid} [get]
-func ReadManyTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) ReadManyTaskHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
 	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
 
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
 	queryParams := r.URL.Query()
 	filters := filtersMap(queryParams)
 
@@ -144,11 +185,15 @@ func ReadManyTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tsk := FullTask{OwnerId: userId}
+	if !actor.CanAccessTasksOf(userId) {
+		e.Error403(service.ErrForbidden)
+		service.ServerResponse(w, e)
+		return
+	}
 
-	tasks, err := tsk.ReadMany(filters)
+	tasks, err := h.Tasks.ReadMany(actor.OrganizationId, userId, filters)
 	if err != nil {
-		e.ReadBodyError(err)
+		e.DBError(err)
 		service.ServerResponse(w, e)
 		return
 	}
@@ -169,20 +214,30 @@ func ReadManyTaskHandler(w http.ResponseWriter, r *http.Request) {
 //	@Description	Get tasks summary for user. Date format: dd-mm-yyyy
 //	@Tags			Task
 //	@Produce		json
+//	@Security		BearerAuth
 //	@Param			user_uuid	path		string	true	"Provide user's uuid"
 //	@Param			start_date	query		string	false	"Start of period"
 //	@Param			end_date	query		string	false	"End  of period"
 //	@Success		200			{object}	Summary{tasks=[]OutputTask}
 //	@Failure		400			{object}	service.ErrorResponse
+//	@Failure		401			{object}	service.ErrorResponse
+//	@Failure		403			{object}	service.ErrorResponse
 //	@Failure		404			{object}	service.ErrorResponse
 //	@Failure		500			{object}	service.ErrorResponse
 //	@Router			/tasks/summary/{user_uuid}  [get]
-func SummaryHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) SummaryHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
 	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
 
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
 	queryParams := r.URL.Query()
 	filters := filtersMap(queryParams)
 
@@ -193,18 +248,22 @@ func SummaryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = validateOwner(userId) //check if owner exists
+	usr, err := h.Users.ReadOne(actor.OrganizationId, userId) //check if owner exists
 	if err != nil {
 		e.DBTaskOwnerNotFound()
 		service.ServerResponse(w, e)
 		return
 	}
 
-	tsk := FullTask{OwnerId: userId}
+	if !actor.CanReadReportsOf(&usr) {
+		e.Error403(service.ErrForbidden)
+		service.ServerResponse(w, e)
+		return
+	}
 
-	tasks, err := tsk.ReadMany(filters)
+	tasks, err := h.Tasks.ReadMany(actor.OrganizationId, userId, filters)
 	if err != nil {
-		e.ReadBodyError(err)
+		e.DBError(err)
 		service.ServerResponse(w, e)
 		return
 	}
@@ -231,14 +290,6 @@ func SummaryHandler(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	usr := user.FullUser{UserId: userId}
-	err = usr.ReadOne()
-	if err != nil {
-		e.DBError(err)
-		service.ServerResponse(w, e)
-		return
-	}
-
 	response := Summary{
 		Name:    usr.Name,
 		Surname: usr.Surname,
@@ -256,23 +307,36 @@ func SummaryHandler(w http.ResponseWriter, r *http.Request) {
 // UpdateTaskHandler godoc
 //
 //	@Summary		Update task
-//	@Description	Update task by UUID
+//	@Description	Update task by UUID. With If-Match the update is applied only if the task wasn't modified since its ETag was read.
 //	@Tags			Task
 //	@Accept			json
 //	@Produce		json
+//	@Security		BearerAuth
 //	@Param			uuid		path		string	true		"Provide task's uuid"
+//	@Param			If-Match	header		string	false		"ETag of the task"
 //	@Param			UpdateTask	data		body	UpdateTask	true	"Partial update possible"
 //	@Success		200			{object}	service.OkResponse
 //	@Failure		400			{object}	service.ErrorResponse
+//	@Failure		401			{object}	service.ErrorResponse
+//	@Failure		403			{object}	service.ErrorResponse
 //	@Failure		404			{object}	service.ErrorResponse
+//	@Failure		412			{object}	service.ErrorResponse
+//	@Failure		413			{object}	service.ErrorResponse
 //	@Failure		500			{object}	service.ErrorResponse
 //	@Router			/task/{uuid} [put]
-func UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
 	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
 
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
 	taskId, err := uuid.Parse(r.PathValue("uuid"))
 	if err != nil {
 		e.UuidParseError(err)
@@ -288,6 +352,20 @@ func UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
+	current, err := authorizeTask(h.Tasks, &actor, taskId)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	version, err := service.IfMatch(r, current.Version)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
 	tsk := UpdateTask{TaskId: taskId}
 
 	err = service.DeserializeJSON(data, &tsk)
@@ -304,13 +382,10 @@ func UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = tsk.UpdatePart()
+	tsk.Version = version
+	err = h.Tasks.UpdatePart(&tsk)
 	if err != nil {
-		if err.Error() == "404" {
-			e.Error404()
-		} else {
-			e.DBError(err)
-		}
+		e.FromError(err)
 		service.ServerResponse(w, e)
 		return
 	}
@@ -328,21 +403,33 @@ func UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
 // DeleteTaskHandler godoc
 //
 //	@Summary		Delete task
-//	@Description	Delete task by UUID
+//	@Description	Delete task by UUID. With If-Match the task is deleted only if it wasn't modified since its ETag was read.
 //	@Tags			Task
 //	@Produce		json
-//	@Param			uuid	path		string	true	"Provide task's uuid"
-//	@Success		200		{object}	service.OkResponse
-//	@Failure		400		{object}	service.ErrorResponse
-//	@Failure		404		{object}	service.ErrorResponse
-//	@Failure		500		{object}	service.ErrorResponse
+//	@Security		BearerAuth
+//	@Param			uuid		path		string	true	"Provide task's uuid"
+//	@Param			If-Match	header		string	false	"ETag of the task"
+//	@Success		204			"No Content"
+//	@Failure		400			{object}	service.ErrorResponse
+//	@Failure		401			{object}	service.ErrorResponse
+//	@Failure		403			{object}	service.ErrorResponse
+//	@Failure		404			{object}	service.ErrorResponse
+//	@Failure		412			{object}	service.ErrorResponse
+//	@Failure		500			{object}	service.ErrorResponse
 //	@Router			/task/{uuid} [delete]
-func DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
 	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
 
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
 	taskId, err := uuid.Parse(r.PathValue("uuid"))
 	if err != nil {
 		e.UuidParseError(err)
@@ -350,15 +437,23 @@ func DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tsk := FullTask{TaskId: taskId}
+	tsk, err := authorizeTask(h.Tasks, &actor, taskId)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	version, err := service.IfMatch(r, tsk.Version)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
 
-	err = tsk.Delete()
+	err = h.Tasks.Delete(tsk.TaskId, version)
 	if err != nil {
-		if err.Error() == "404" {
-			e.Error404()
-		} else {
-			e.DBError(err)
-		}
+		e.FromError(err)
 		service.ServerResponse(w, e)
 		return
 	}
@@ -366,7 +461,7 @@ func DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	msg := "Task deleted successfully"
 
 	service.ServerResponse(w, service.OkResponse{
-		Code:    http.StatusOK,
+		Code:    http.StatusNoContent,
 		Message: msg,
 		Data:    "",
 	})
@@ -379,53 +474,45 @@ func DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 //	@Description	Start task by UUID
 //	@Tags			Task
 //	@Produce		json
+//	@Security		BearerAuth
 //	@Param			uuid	path		string	true	"Provide task's uuid"
 //	@Success		200		{object}	service.OkResponse
 //	@Failure		400		{object}	service.ErrorResponse
+//	@Failure		401		{object}	service.ErrorResponse
+//	@Failure		403		{object}	service.ErrorResponse
 //	@Failure		404		{object}	service.ErrorResponse
 //	@Failure		500		{object}	service.ErrorResponse
 //	@Router			/task/start/{uuid} [get]
-func StartTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) StartTaskHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
 	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
 
-	taskId, err := uuid.Parse(r.PathValue("uuid"))
+	actor, err := user.CurrentUser(r, h.Users)
 	if err != nil {
-		e.UuidParseError(err)
+		e.Error401(err)
 		service.ServerResponse(w, e)
 		return
 	}
 
-	tsk := FullTask{TaskId: taskId}
-	err = tsk.ReadOne()
+	taskId, err := uuid.Parse(r.PathValue("uuid"))
 	if err != nil {
-		if err.Error() == "record not found" {
-			e.Error404()
-		} else {
-			e.DBError(err)
-		}
+		e.UuidParseError(err)
 		service.ServerResponse(w, e)
 		return
 	}
 
-	if !tsk.StartAt.IsZero() {
-		if !tsk.FinishAt.IsZero() {
-			e.TaskIsAlreadyFinishedError()
-			service.ServerResponse(w, e)
-			return
-		}
-		e.TaskIsAlreadyStartedError()
+	tsk, err := authorizeTask(h.Tasks, &actor, taskId)
+	if err != nil {
+		e.FromError(err)
 		service.ServerResponse(w, e)
 		return
 	}
 
-	tsk.StartAt = time.Now()
-
-	err = tsk.UpdateFull()
+	err = startTask(h.Tasks, &tsk)
 	if err != nil {
-		e.DBError(err)
+		e.FromError(err)
 		service.ServerResponse(w, e)
 		return
 	}
@@ -446,18 +533,28 @@ func StartTaskHandler(w http.ResponseWriter, r *http.Request) {
 //	@Description	Finish task by UUID
 //	@Tags			Task
 //	@Produce		json
+//	@Security		BearerAuth
 //	@Param			uuid	path		string	true	"Provide task's uuid"
 //	@Success		200		{object}	service.OkResponse
 //	@Failure		400		{object}	service.ErrorResponse
+//	@Failure		401		{object}	service.ErrorResponse
+//	@Failure		403		{object}	service.ErrorResponse
 //	@Failure		404		{object}	service.ErrorResponse
 //	@Failure		500		{object}	service.ErrorResponse
 //	@Router			/task/finish/{uuid} [get]
-func FinishTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) FinishTaskHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
 	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
 
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
 	taskId, err := uuid.Parse(r.PathValue("uuid"))
 	if err != nil {
 		e.UuidParseError(err)
@@ -465,55 +562,108 @@ func FinishTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tsk := FullTask{TaskId: taskId}
+	tsk, err := authorizeTask(h.Tasks, &actor, taskId)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
 
-	err = tsk.ReadOne()
+	err = finishTask(h.Tasks, &tsk)
 	if err != nil {
-		if err.Error() == "record not found" {
-			e.Error404()
-		} else {
-			e.DBError(err)
-		}
+		e.FromError(err)
 		service.ServerResponse(w, e)
 		return
 	}
 
-	fmt.Println(tsk.StartAt, tsk.StartAt.IsZero())
+	duration := time.Duration(tsk.Duration)
+
+	msg := "Task finished successfully"
+
+	service.ServerResponse(w, service.OkResponse{
+		Code:    http.StatusOK,
+		Message: msg,
+		Data: fmt.Sprintf("Finished at: %s, Duration: %02d:%02d:%02d",
+			tsk.FinishAt.Format("15:04:05 02-01-2006"),
+			int(duration.Hours()),
+			int(duration.Minutes())%60,
+			int(duration.Seconds())%60,
+		),
+	})
+	log.Info(msg)
+}
+
+// BatchTaskHandler godoc
+//
+//	@Summary		Batch task operations
+//	@Description	Execute a list of create/update/delete/start/finish operations in a single transaction. Created tasks belong to the authenticated user.
+//	@Description	Mode "atomic" (default) rolls back everything on the first failure, "best_effort" rolls back only failed operations.
+//	@Tags			Task
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			Batch	request		body	BatchRequest	true	"Mode and list of operations"
+//	@Success		200		{object}	service.OkResponse{data=BatchResult}
+//	@Failure		400		{object}	service.ErrorResponse
+//	@Failure		401		{object}	service.ErrorResponse
+//	@Failure		403		{object}	service.ErrorResponse
+//	@Failure		413		{object}	service.ErrorResponse
+//	@Failure		500		{object}	service.ErrorResponse
+//	@Router			/tasks/batch [post]
+func (h *Handler) BatchTaskHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
 
-	if tsk.StartAt.IsZero() {
-		e.TaskNotStartedError()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		e.ReadBodyError(err)
 		service.ServerResponse(w, e)
 		return
 	}
+	defer r.Body.Close()
 
-	if !tsk.FinishAt.IsZero() {
-		e.TaskIsAlreadyFinishedError()
+	var batch BatchRequest
+	err = service.DeserializeJSON(data, &batch)
+	if err != nil {
+		e.DeserializeError(err)
 		service.ServerResponse(w, e)
 		return
 	}
 
-	tsk.FinishAt = time.Now()
-	duration := tsk.FinishAt.Sub(tsk.StartAt)
-	tsk.Duration = int64(duration)
+	err = batch.validate()
+	if err != nil {
+		e.ValidationError(err)
+		service.ServerResponse(w, e)
+		return
+	}
 
-	err = tsk.UpdateFull()
+	result, err := batch.Execute(h.Tasks, &actor)
 	if err != nil {
 		e.DBError(err)
 		service.ServerResponse(w, e)
 		return
 	}
 
-	msg := "Task finished successfully"
+	msg := "Batch processed"
 
 	service.ServerResponse(w, service.OkResponse{
 		Code:    http.StatusOK,
 		Message: msg,
-		Data: fmt.Sprintf("Finished at: %s, Duration: %02d:%02d:%02d",
-			tsk.FinishAt.Format("15:04:05 02-01-2006"),
-			int(duration.Hours()),
-			int(duration.Minutes())%60,
-			int(duration.Seconds())%60,
-		),
+		Data:    result,
 	})
-	log.Info(msg)
+	log.WithFields(log.Fields{
+		"mode":      result.Mode,
+		"committed": result.Committed,
+		"succeeded": result.Succeeded,
+		"failed":    result.Failed,
+	}).Info(msg)
 }
@@ -0,0 +1,144 @@
+package task_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time_tracker/api/apitest"
+	"time_tracker/api/auth"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/service"
+	"time_tracker/api/task"
+	"time_tracker/api/user"
+)
+
+const concurrentRequests = 20
+
+// newTestServer serves the task handlers on a migrated in-memory SQLite
+// database and returns its URL, the access token of the owner of the
+// returned task and the task repository.
+func newTestServer(t *testing.T) (string, string, task.FullTask, *task.GormRepository) {
+	t.Helper()
+	apitest.Setup()
+
+	DB := apitest.NewDB(t)
+	users := user.NewGormRepository(DB)
+	tasks := task.NewGormRepository(DB)
+	_, owner := apitest.CreateOrganization(t, task.NewGormStore(DB), enrichment.NoopProvider{})
+
+	tsk := task.FullTask{TaskId: uuid.New(), OwnerId: owner.UserId, OrganizationId: owner.OrganizationId, Title: "Report"}
+	err := tasks.Create(&tsk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := auth.IssueTokenPair(owner.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := http.NewServeMux()
+	task.NewHandler(tasks, users).AddRoutes(router)
+	ts := httptest.NewServer(auth.NewHandler(auth.NewMemoryRepository(), users).Middleware(router))
+	t.Cleanup(ts.Close)
+
+	return ts.URL, tokens.AccessToken, tsk, tasks
+}
+
+// requestConcurrently sends n requests at once and counts responses by
+// status and problem code, "200" for successful ones.
+func requestConcurrently(t *testing.T, url, token string, n int) map[string]int {
+	t.Helper()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = map[string]int{}
+		start   = make(chan struct{})
+	)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			result, err := request(url, token)
+			if err != nil {
+				result = err.Error()
+			}
+
+			mu.Lock()
+			results[result]++
+			mu.Unlock()
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	return results
+}
+
+func request(url, token string) (string, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return "200", nil
+	}
+
+	var problem service.ErrorResponse
+	err = json.NewDecoder(resp.Body).Decode(&problem)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %s", resp.StatusCode, problem.Code), nil
+}
+
+func TestConcurrentStartFinish(t *testing.T) {
+	url, token, tsk, tasks := newTestServer(t)
+
+	results := requestConcurrently(t, fmt.Sprintf("%s/api/v1/task/start/%s", url, tsk.TaskId), token, concurrentRequests)
+	if results["200"] != 1 || results["409 task.already_started"] != concurrentRequests-1 {
+		t.Fatalf("expected one start and %d conflicts, got %v", concurrentRequests-1, results)
+	}
+
+	started, err := tasks.ReadOne(tsk.OrganizationId, tsk.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results = requestConcurrently(t, fmt.Sprintf("%s/api/v1/task/finish/%s", url, tsk.TaskId), token, concurrentRequests)
+	if results["200"] != 1 || results["409 task.already_finished"] != concurrentRequests-1 {
+		t.Fatalf("expected one finish and %d conflicts, got %v", concurrentRequests-1, results)
+	}
+
+	finished, err := tasks.ReadOne(tsk.OrganizationId, tsk.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !finished.StartAt.Equal(started.StartAt) || finished.Version != 3 {
+		t.Fatalf("expected the start kept and two writes, got start %v and version %d", finished.StartAt, finished.Version)
+	}
+	if finished.Duration != int64(finished.FinishAt.Sub(finished.StartAt)) {
+		t.Fatalf("duration %d doesn't match start %v and finish %v", finished.Duration, finished.StartAt, finished.FinishAt)
+	}
+
+	results = requestConcurrently(t, fmt.Sprintf("%s/api/v1/task/start/%s", url, tsk.TaskId), token, concurrentRequests)
+	if results["409 task.already_finished"] != concurrentRequests {
+		t.Fatalf("expected the finished task not started again, got %v", results)
+	}
+}
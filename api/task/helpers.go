@@ -2,39 +2,55 @@ package task
 
 import (
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"net/url"
 	"time"
+	"time_tracker/api/service"
 	"time_tracker/api/user"
+	"time_tracker/api/validation"
 )
 
 func (f *FullTask) validateNewTask() error {
-	p, err := uuid.Parse(f.OwnerId.String())
-	if err != nil {
-		return errors.New("incorrect user ID")
-	}
+	v := validation.New()
+	v.Check(f.OwnerId != uuid.Nil, "owner_id", validation.RuleRequired, "owner ID is required")
+	v.Required("title", f.Title)
+	return v.Err()
+}
 
-	if p == uuid.Nil {
-		return errors.New("owner ID is required")
-	}
+func (u *UpdateTask) validateOnUpdate() error {
+	v := validation.New()
+	v.Required("title", u.Title)
+	return v.Err()
+}
 
-	err = validateOwner(f.OwnerId)
-	if err != nil {
-		return err
+func (b *BatchRequest) validate() error {
+	if b.Mode == "" {
+		b.Mode = BatchModeAtomic
 	}
 
-	if len(f.Title) == 0 {
-		return errors.New("title is required")
-	}
+	v := validation.New()
+	v.OneOf("mode", b.Mode, BatchModeAtomic, BatchModeBestEffort)
+	v.Check(len(b.Operations) > 0, "operations", validation.RuleRequired, "operations list can't be empty")
+	v.Check(len(b.Operations) <= batchMaxOperations, "operations", validation.RuleMaxItems,
+		fmt.Sprintf("too many operations, max is %d", batchMaxOperations))
 
-	return nil
-}
+	for i, op := range b.Operations {
+		ov := v.At(fmt.Sprintf("/operations/%d", i))
+		if !ov.OneOf("op", op.Op, "create", "update", "delete", "start", "finish") {
+			continue
+		}
 
-func (u *UpdateTask) validateOnUpdate() error {
-	if len(u.Title) == 0 {
-		return errors.New("title can't be ommited or be blank")
+		if op.Op != "create" {
+			ov.Check(op.TaskId != uuid.Nil, "task_id", validation.RuleRequired, "task_id field can't be empty")
+		}
+
+		if op.Op == "create" || op.Op == "update" {
+			ov.Required("title", op.Title)
+		}
 	}
-	return nil
+
+	return v.Err()
 }
 
 func filtersMap(queryParams url.Values) map[string]time.Time {
@@ -66,11 +82,60 @@ func filtersMap(queryParams url.Values) map[string]time.Time {
 	return filters
 }
 
-func validateOwner(id uuid.UUID) error {
-	var owner user.FullUser
-	err := DB.Where("user_id = ?", id).First(&owner).Error
+// authorizeTask loads the task and checks that actor may access it.
+func authorizeTask(tasks TaskRepository, actor *user.FullUser, taskId uuid.UUID) (FullTask, error) {
+	tsk, err := tasks.ReadOne(actor.OrganizationId, taskId)
+	if err != nil {
+		return tsk, err
+	}
+
+	if !actor.CanAccessTasksOf(tsk.OwnerId) {
+		return tsk, service.ErrForbidden
+	}
+	return tsk, nil
+}
+
+// startTask starts the authorized task. The write is conditional on the
+// stored task not being started, so of concurrent calls only one succeeds.
+func startTask(tasks TaskRepository, tsk *FullTask) error {
+	err := tsk.start(time.Now())
+	if err != nil {
+		return err
+	}
+
+	err = tasks.Start(tsk)
+	if errors.Is(err, errStateChanged) {
+		return stateError(tasks, tsk, (*FullTask).start)
+	}
+	return err
+}
+
+// finishTask finishes the authorized task. The write is conditional on the
+// stored task being started and not finished.
+func finishTask(tasks TaskRepository, tsk *FullTask) error {
+	err := tsk.finish(time.Now())
 	if err != nil {
 		return err
 	}
-	return nil
+
+	err = tasks.Finish(tsk)
+	if errors.Is(err, errStateChanged) {
+		return stateError(tasks, tsk, (*FullTask).finish)
+	}
+	return err
+}
+
+// stateError re-reads the task whose conditional write failed and reports
+// why the transition can't be applied to it anymore.
+func stateError(tasks TaskRepository, tsk *FullTask, transition func(*FullTask, time.Time) error) error {
+	current, err := tasks.ReadOne(tsk.OrganizationId, tsk.TaskId)
+	if err != nil {
+		return err
+	}
+
+	err = transition(&current, time.Now())
+	if err == nil {
+		return service.ErrConflict
+	}
+	return err
 }
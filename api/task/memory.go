@@ -0,0 +1,344 @@
+package task
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"sort"
+	"sync"
+	"time"
+	"time_tracker/api/service"
+)
+
+// MemoryRepository keeps tasks in memory and is meant for tests. It mimics
+// soft deletes and error values of GormRepository. Transactions are
+// serialized and rolled back by restoring a snapshot, writes made outside of
+// a running transaction are not isolated from it.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	tx     sync.Mutex
+	nextId uint
+	tasks  []FullTask
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{}
+}
+
+func (m *MemoryRepository) Create(tsk *FullTask) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextId++
+	now := time.Now()
+	tsk.ID, tsk.CreatedAt, tsk.UpdatedAt = m.nextId, now, now
+	if tsk.Version == 0 {
+		tsk.Version = 1
+	}
+	m.tasks = append(m.tasks, *tsk)
+	return nil
+}
+
+func (m *MemoryRepository) ReadOne(organizationId, taskId uuid.UUID) (FullTask, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.find(taskId)
+	if i < 0 || m.tasks[i].OrganizationId != organizationId {
+		return FullTask{}, gorm.ErrRecordNotFound
+	}
+	return m.tasks[i], nil
+}
+
+func (m *MemoryRepository) ReadMany(organizationId, ownerId uuid.UUID, filters map[string]time.Time) ([]FullTask, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var tasks []FullTask
+	for _, tsk := range m.tasks {
+		if tsk.DeletedAt.Valid || tsk.OwnerId != ownerId || tsk.OrganizationId != organizationId {
+			continue
+		}
+		if tsk.FinishAt.Before(filters["start_date"]) || tsk.FinishAt.After(filters["end_date"]) {
+			continue
+		}
+		tasks = append(tasks, tsk)
+	}
+	return tasks, nil
+}
+
+func (m *MemoryRepository) Start(tsk *FullTask) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.find(tsk.TaskId)
+	if i < 0 || !m.tasks[i].StartAt.IsZero() {
+		return errStateChanged
+	}
+
+	stored := &m.tasks[i]
+	stored.StartAt = tsk.StartAt
+	stored.Version++
+	stored.UpdatedAt = time.Now()
+	tsk.Version = stored.Version
+	return nil
+}
+
+func (m *MemoryRepository) Finish(tsk *FullTask) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.find(tsk.TaskId)
+	if i < 0 || m.tasks[i].StartAt.IsZero() || !m.tasks[i].FinishAt.IsZero() {
+		return errStateChanged
+	}
+
+	stored := &m.tasks[i]
+	stored.FinishAt = tsk.FinishAt
+	stored.Duration = tsk.Duration
+	stored.Version++
+	stored.UpdatedAt = time.Now()
+	tsk.Version = stored.Version
+	return nil
+}
+
+func (m *MemoryRepository) UpdatePart(tsk *UpdateTask) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.findVersion(tsk.TaskId, tsk.Version)
+	if err != nil {
+		return err
+	}
+
+	if tsk.Title != "" {
+		m.tasks[i].Title = tsk.Title
+	}
+	if tsk.Content != "" {
+		m.tasks[i].Content = tsk.Content
+	}
+	m.tasks[i].Version++
+	m.tasks[i].UpdatedAt = time.Now()
+	return nil
+}
+
+func (m *MemoryRepository) Delete(taskId uuid.UUID, version int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.findVersion(taskId, version)
+	if err != nil {
+		return err
+	}
+	m.tasks[i].Version++
+	m.tasks[i].DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (m *MemoryRepository) ReadDeleted(organizationId uuid.UUID) ([]FullTask, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var tasks []FullTask
+	for _, tsk := range m.tasks {
+		if tsk.DeletedAt.Valid && tsk.OrganizationId == organizationId {
+			tasks = append(tasks, tsk)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].DeletedAt.Time.After(tasks[j].DeletedAt.Time)
+	})
+	return tasks, nil
+}
+
+func (m *MemoryRepository) ReadDeletedOne(organizationId, taskId uuid.UUID) (FullTask, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.findDeleted(taskId)
+	if i < 0 || m.tasks[i].OrganizationId != organizationId {
+		return FullTask{}, gorm.ErrRecordNotFound
+	}
+	return m.tasks[i], nil
+}
+
+func (m *MemoryRepository) Restore(taskId uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findDeleted(taskId)
+	if i < 0 {
+		return service.ErrNotFound
+	}
+	m.tasks[i].DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+func (m *MemoryRepository) Purge(taskId uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findDeleted(taskId)
+	if i < 0 {
+		return service.ErrNotFound
+	}
+	m.tasks = append(m.tasks[:i], m.tasks[i+1:]...)
+	return nil
+}
+
+func (m *MemoryRepository) PurgeDeletedBefore(t time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int64
+	kept := m.tasks[:0]
+	for _, tsk := range m.tasks {
+		if tsk.DeletedAt.Valid && tsk.DeletedAt.Time.Before(t) {
+			purged++
+			continue
+		}
+		kept = append(kept, tsk)
+	}
+	m.tasks = kept
+	return purged, nil
+}
+
+func (m *MemoryRepository) CountByOwner(ownerId uuid.UUID) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, tsk := range m.tasks {
+		if !tsk.DeletedAt.Valid && tsk.OwnerId == ownerId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MemoryRepository) CountDeletedByOwner(ownerId uuid.UUID) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, tsk := range m.tasks {
+		if tsk.DeletedAt.Valid && tsk.OwnerId == ownerId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MemoryRepository) DeleteByOwner(ownerId uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, tsk := range m.tasks {
+		if !tsk.DeletedAt.Valid && tsk.OwnerId == ownerId {
+			m.tasks[i].DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		}
+	}
+	return nil
+}
+
+func (m *MemoryRepository) ReassignOwner(ownerId, newOwnerId uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, tsk := range m.tasks {
+		if !tsk.DeletedAt.Valid && tsk.OwnerId == ownerId {
+			m.tasks[i].OwnerId = newOwnerId
+			m.tasks[i].Version++
+			m.tasks[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+func (m *MemoryRepository) PurgeByOwner(ownerId uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.tasks[:0]
+	for _, tsk := range m.tasks {
+		if !(tsk.DeletedAt.Valid && tsk.OwnerId == ownerId) {
+			kept = append(kept, tsk)
+		}
+	}
+	m.tasks = kept
+	return nil
+}
+
+func (m *MemoryRepository) RestoreByOwner(ownerId uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, tsk := range m.tasks {
+		if tsk.DeletedAt.Valid && tsk.OwnerId == ownerId {
+			m.tasks[i].DeletedAt = gorm.DeletedAt{}
+		}
+	}
+	return nil
+}
+
+func (m *MemoryRepository) Transaction(fn func(tasks TaskRepository) error) error {
+	m.tx.Lock()
+	defer m.tx.Unlock()
+	return m.savepoint(fn)
+}
+
+// savepoint runs fn and restores the state taken before it if fn fails.
+func (m *MemoryRepository) savepoint(fn func(tasks TaskRepository) error) error {
+	m.mu.RLock()
+	snapshot := append([]FullTask(nil), m.tasks...)
+	nextId := m.nextId
+	m.mu.RUnlock()
+
+	err := fn(memoryTx{m})
+	if err != nil {
+		m.mu.Lock()
+		m.tasks, m.nextId = snapshot, nextId
+		m.mu.Unlock()
+	}
+	return err
+}
+
+// find returns index of the live task or -1.
+func (m *MemoryRepository) find(taskId uuid.UUID) int {
+	for i, tsk := range m.tasks {
+		if !tsk.DeletedAt.Valid && tsk.TaskId == taskId {
+			return i
+		}
+	}
+	return -1
+}
+
+// findVersion finds the live task, a non-zero version must match the stored one.
+func (m *MemoryRepository) findVersion(taskId uuid.UUID, version int64) (int, error) {
+	i := m.find(taskId)
+	if version != 0 && (i < 0 || m.tasks[i].Version != version) {
+		return -1, service.ErrPreconditionFailed
+	}
+	if i < 0 {
+		return -1, service.ErrNotFound
+	}
+	return i, nil
+}
+
+func (m *MemoryRepository) findDeleted(taskId uuid.UUID) int {
+	for i, tsk := range m.tasks {
+		if tsk.DeletedAt.Valid && tsk.TaskId == taskId {
+			return i
+		}
+	}
+	return -1
+}
+
+// memoryTx is the repository passed into transactions, nested transactions
+// become savepoints instead of waiting for the outer one.
+type memoryTx struct {
+	*MemoryRepository
+}
+
+func (t memoryTx) Transaction(fn func(tasks TaskRepository) error) error {
+	return t.savepoint(fn)
+}
@@ -1,33 +1,35 @@
 package task
 
 import (
-	"errors"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"time"
+	"time_tracker/api/service"
 )
 
 type FullTask struct {
-	gorm.Model `json:"-"`
-	TaskId     uuid.UUID `json:"task_id" example:"00000000-0000-0000-0000-000000000000" extensions:"x-order=1"`
-	OwnerId    uuid.UUID `json:"owner_id" example:"00000000-0000-0000-0000-000000000000" extensions:"x-order=2"`
-	Title      string    `json:"title" example:"Title" extensions:"x-order=3"`
-	Content    string    `json:"content" example:"Description" extensions:"x-order=4"`
-	StartAt    time.Time `json:"start_at" example:"0001-01-01 00:00:00 +0000 UTC" extensions:"x-order=5"`
-	FinishAt   time.Time `json:"end_at" example:"0001-01-01 00:00:00 +0000 UTC" extensions:"x-order=6"`
-	Duration   int64     `json:"duration" example:"0" extensions:"x-order=7"`
+	gorm.Model     `json:"-"`
+	TaskId         uuid.UUID `json:"task_id" example:"00000000-0000-0000-0000-000000000000" extensions:"x-order=1"`
+	OwnerId        uuid.UUID `json:"owner_id" example:"00000000-0000-0000-0000-000000000000" extensions:"x-order=2"`
+	Title          string    `json:"title" example:"Title" extensions:"x-order=3"`
+	Content        string    `json:"content" example:"Description" extensions:"x-order=4"`
+	StartAt        time.Time `json:"start_at" example:"0001-01-01 00:00:00 +0000 UTC" extensions:"x-order=5"`
+	FinishAt       time.Time `json:"end_at" example:"0001-01-01 00:00:00 +0000 UTC" extensions:"x-order=6"`
+	Duration       int64     `json:"duration" example:"0" extensions:"x-order=7"`
+	OrganizationId uuid.UUID `json:"-" gorm:"index"`
+	Version        int64     `json:"-" gorm:"not null;default:1"`
 }
 
 type CreateTask struct {
-	OwnerId uuid.UUID `json:"owner_id" extensions:"x-order=1"`
-	Title   string    `json:"title" extensions:"x-order=2"`
-	Content string    `json:"content" extensions:"x-order=3"`
+	Title   string `json:"title" extensions:"x-order=1"`
+	Content string `json:"content" extensions:"x-order=2"`
 }
 
 type UpdateTask struct {
 	TaskId  uuid.UUID `json:"-"`
 	Title   string    `json:"title" extensions:"x-order=1"`
 	Content string    `json:"content" extensions:"x-order=2"`
+	Version int64     `json:"-" gorm:"-"`
 }
 
 type OutputTask struct {
@@ -43,73 +45,63 @@ type Summary struct {
 	Tasks         []OutputTask `json:"tasks" extensions:"x-order=4"`
 }
 
-func (f *FullTask) TableName() string {
-	return "tasks"
+type BatchOperation struct {
+	Op      string    `json:"op" example:"start" enums:"create,update,delete,start,finish" extensions:"x-order=1"`
+	TaskId  uuid.UUID `json:"task_id" example:"00000000-0000-0000-0000-000000000000" extensions:"x-order=2"`
+	Title   string    `json:"title" extensions:"x-order=3"`
+	Content string    `json:"content" extensions:"x-order=4"`
 }
 
-func (f *FullTask) Create() error {
-	err := DB.Create(f).Error
-	if err != nil {
-		return err
-	}
-	return nil
+type BatchRequest struct {
+	Mode       string           `json:"mode" example:"atomic" enums:"atomic,best_effort" extensions:"x-order=1"`
+	Operations []BatchOperation `json:"operations" extensions:"x-order=2"`
 }
 
-func (f *FullTask) ReadOne() error {
-	err := DB.Where("task_id = ?", f.TaskId).First(f).Error
-	if err != nil {
-		return err
-	}
-	return nil
+type BatchOperationResult struct {
+	Index   int       `json:"index" extensions:"x-order=1"`
+	Op      string    `json:"op" extensions:"x-order=2"`
+	TaskId  uuid.UUID `json:"task_id" extensions:"x-order=3"`
+	Status  int       `json:"status" example:"200" extensions:"x-order=4"`
+	Code    string    `json:"code,omitempty" example:"task.already_started" extensions:"x-order=5"`
+	Message string    `json:"message" extensions:"x-order=6"`
 }
 
-func (f *FullTask) ReadMany(filters map[string]time.Time) ([]FullTask, error) {
-	var tasks []FullTask
-	err := DB.
-		Where("owner_id = ?", f.OwnerId).
-		Where("finish_at BETWEEN ? and ?", filters["start_date"], filters["end_date"]).
-		Find(&tasks).Error
-	if err != nil {
-		return nil, err
-	}
-	return tasks, nil
+type BatchResult struct {
+	Mode      string                 `json:"mode" extensions:"x-order=1"`
+	Committed bool                   `json:"committed" extensions:"x-order=2"`
+	Succeeded int                    `json:"succeeded" extensions:"x-order=3"`
+	Failed    int                    `json:"failed" extensions:"x-order=4"`
+	Results   []BatchOperationResult `json:"results" extensions:"x-order=5"`
 }
 
-func (f *FullTask) UpdateFull() error {
-	result := DB.Where("task_id = ?", f.TaskId).Updates(f)
-
-	if result.Error != nil {
-		return result.Error
-	}
-
-	if result.RowsAffected == 0 {
-		return errors.New("task not found")
-	}
-
-	return nil
+func (f *FullTask) TableName() string {
+	return "tasks"
 }
 
-func (u *UpdateTask) UpdatePart() error {
-	result := DB.Model(&FullTask{}).Where("task_id = ?", u.TaskId).Updates(u)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return errors.New("404")
+// start marks the task started at now, a task can be started only once.
+func (f *FullTask) start(now time.Time) error {
+	if !f.StartAt.IsZero() {
+		if !f.FinishAt.IsZero() {
+			return service.ErrTaskAlreadyFinished
+		}
+		return service.ErrTaskAlreadyStarted
 	}
+
+	f.StartAt = now
 	return nil
 }
 
-func (f *FullTask) Delete() error {
-	result := DB.Where("task_id = ?", f.TaskId).Delete(f)
-
-	if result.Error != nil {
-		return result.Error
+// finish marks the started task finished at now and stores its duration.
+func (f *FullTask) finish(now time.Time) error {
+	if f.StartAt.IsZero() {
+		return service.ErrTaskNotStarted
 	}
 
-	if result.RowsAffected == 0 {
-		return errors.New("404")
+	if !f.FinishAt.IsZero() {
+		return service.ErrTaskAlreadyFinished
 	}
 
+	f.FinishAt = now
+	f.Duration = int64(f.FinishAt.Sub(f.StartAt))
 	return nil
 }
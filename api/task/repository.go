@@ -0,0 +1,288 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+	"time_tracker/api/service"
+	"time_tracker/db"
+)
+
+// TaskRepository stores tasks. Lookups are limited to one organization,
+// writes expect the task to be authorized by the caller beforehand. Every
+// write increments the task version; a non-zero version passed to a write
+// makes it conditional and ErrPreconditionFailed is returned on mismatch.
+type TaskRepository interface {
+	Create(tsk *FullTask) error
+	ReadOne(organizationId, taskId uuid.UUID) (FullTask, error)
+	// ReadMany returns owner's tasks finished between start_date and end_date filters.
+	ReadMany(organizationId, ownerId uuid.UUID, filters map[string]time.Time) ([]FullTask, error)
+	// Start saves StartAt of the task only if it isn't started yet, Finish saves
+	// FinishAt and Duration only if it is started and not finished. Both return
+	// errStateChanged when the stored task is not in the expected state.
+	Start(tsk *FullTask) error
+	Finish(tsk *FullTask) error
+	UpdatePart(tsk *UpdateTask) error
+	Delete(taskId uuid.UUID, version int64) error
+	// ReadDeleted lists soft-deleted tasks of the organization, most recently deleted first.
+	ReadDeleted(organizationId uuid.UUID) ([]FullTask, error)
+	ReadDeletedOne(organizationId, taskId uuid.UUID) (FullTask, error)
+	Restore(taskId uuid.UUID) error
+	// Purge permanently deletes the soft-deleted task.
+	Purge(taskId uuid.UUID) error
+	// PurgeDeletedBefore permanently deletes tasks of all organizations soft-deleted before t.
+	PurgeDeletedBefore(t time.Time) (int64, error)
+	CountByOwner(ownerId uuid.UUID) (int64, error)
+	CountDeletedByOwner(ownerId uuid.UUID) (int64, error)
+	DeleteByOwner(ownerId uuid.UUID) error
+	ReassignOwner(ownerId, newOwnerId uuid.UUID) error
+	PurgeByOwner(ownerId uuid.UUID) error
+	RestoreByOwner(ownerId uuid.UUID) error
+	// Transaction runs fn atomically, nested calls roll back to their own savepoint.
+	Transaction(fn func(tasks TaskRepository) error) error
+}
+
+// errStateChanged means the task left the state a conditional write expected,
+// callers re-read the task to report why.
+var errStateChanged = errors.New("task state changed concurrently")
+
+type GormRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRepository(db *gorm.DB) *GormRepository {
+	return &GormRepository{db: db}
+}
+
+func (g *GormRepository) Create(tsk *FullTask) error {
+	err := g.db.Create(tsk).Error
+	if err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+func (g *GormRepository) ReadOne(organizationId, taskId uuid.UUID) (FullTask, error) {
+	var tsk FullTask
+	err := g.db.Where("task_id = ? AND organization_id = ?", taskId, organizationId).First(&tsk).Error
+	if err != nil {
+		return FullTask{}, err
+	}
+	return tsk, nil
+}
+
+func (g *GormRepository) ReadMany(organizationId, ownerId uuid.UUID, filters map[string]time.Time) ([]FullTask, error) {
+	var tasks []FullTask
+	err := g.db.
+		Where("owner_id = ? AND organization_id = ?", ownerId, organizationId).
+		Where("finish_at BETWEEN ? and ?", filters["start_date"], filters["end_date"]).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (g *GormRepository) Start(tsk *FullTask) error {
+	result := g.db.Model(&FullTask{}).
+		Where("task_id = ? AND start_at = ?", tsk.TaskId, time.Time{}).
+		Updates(map[string]interface{}{"start_at": tsk.StartAt, "version": gorm.Expr("version + 1")})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errStateChanged
+	}
+
+	tsk.Version++
+	return nil
+}
+
+func (g *GormRepository) Finish(tsk *FullTask) error {
+	result := g.db.Model(&FullTask{}).
+		Where("task_id = ? AND start_at <> ? AND finish_at = ?", tsk.TaskId, time.Time{}, time.Time{}).
+		Updates(map[string]interface{}{
+			"finish_at": tsk.FinishAt,
+			"duration":  tsk.Duration,
+			"version":   gorm.Expr("version + 1"),
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errStateChanged
+	}
+
+	tsk.Version++
+	return nil
+}
+
+func (g *GormRepository) UpdatePart(tsk *UpdateTask) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		err := bumpVersion(tx, tsk.TaskId, tsk.Version)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&FullTask{}).Where("task_id = ?", tsk.TaskId).Updates(tsk).Error
+	})
+}
+
+func (g *GormRepository) Delete(taskId uuid.UUID, version int64) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		err := bumpVersion(tx, taskId, version)
+		if err != nil {
+			return err
+		}
+		return tx.Where("task_id = ?", taskId).Delete(&FullTask{}).Error
+	})
+}
+
+func (g *GormRepository) ReadDeleted(organizationId uuid.UUID) ([]FullTask, error) {
+	var tasks []FullTask
+	err := g.db.Unscoped().
+		Where("organization_id = ? AND deleted_at IS NOT NULL", organizationId).
+		Order("deleted_at DESC").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (g *GormRepository) ReadDeletedOne(organizationId, taskId uuid.UUID) (FullTask, error) {
+	var tsk FullTask
+	err := g.db.Unscoped().
+		Where("task_id = ? AND organization_id = ? AND deleted_at IS NOT NULL", taskId, organizationId).
+		First(&tsk).Error
+	if err != nil {
+		return FullTask{}, err
+	}
+	return tsk, nil
+}
+
+func (g *GormRepository) Restore(taskId uuid.UUID) error {
+	result := g.db.Unscoped().Model(&FullTask{}).
+		Where("task_id = ? AND deleted_at IS NOT NULL", taskId).
+		Update("deleted_at", nil)
+
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return service.ErrNotFound
+	}
+	return nil
+}
+
+func (g *GormRepository) Purge(taskId uuid.UUID) error {
+	result := g.db.Unscoped().Where("task_id = ? AND deleted_at IS NOT NULL", taskId).Delete(&FullTask{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return service.ErrNotFound
+	}
+	return nil
+}
+
+func (g *GormRepository) PurgeDeletedBefore(t time.Time) (int64, error) {
+	result := g.db.Unscoped().Where("deleted_at < ?", t).Delete(&FullTask{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+func (g *GormRepository) CountByOwner(ownerId uuid.UUID) (int64, error) {
+	var count int64
+	err := g.db.Model(&FullTask{}).Where("owner_id = ?", ownerId).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (g *GormRepository) CountDeletedByOwner(ownerId uuid.UUID) (int64, error) {
+	var count int64
+	err := g.db.Unscoped().Model(&FullTask{}).
+		Where("owner_id = ? AND deleted_at IS NOT NULL", ownerId).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (g *GormRepository) DeleteByOwner(ownerId uuid.UUID) error {
+	return g.db.Where("owner_id = ?", ownerId).Delete(&FullTask{}).Error
+}
+
+func (g *GormRepository) ReassignOwner(ownerId, newOwnerId uuid.UUID) error {
+	err := g.db.Model(&FullTask{}).Where("owner_id = ?", ownerId).
+		Updates(map[string]interface{}{"owner_id": newOwnerId, "version": gorm.Expr("version + 1")}).Error
+	if err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+func (g *GormRepository) PurgeByOwner(ownerId uuid.UUID) error {
+	return g.db.Unscoped().Where("owner_id = ? AND deleted_at IS NOT NULL", ownerId).Delete(&FullTask{}).Error
+}
+
+func (g *GormRepository) RestoreByOwner(ownerId uuid.UUID) error {
+	return g.db.Unscoped().Model(&FullTask{}).
+		Where("owner_id = ? AND deleted_at IS NOT NULL", ownerId).
+		Update("deleted_at", nil).Error
+}
+
+func (g *GormRepository) Transaction(fn func(tasks TaskRepository) error) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&GormRepository{db: tx})
+	})
+}
+
+// bumpVersion increments the version of the live task, a non-zero version
+// makes it conditional on the stored one. Running it first in a transaction
+// also locks the row against concurrent writers.
+func bumpVersion(tx *gorm.DB, taskId uuid.UUID, version int64) error {
+	query := tx.Model(&FullTask{}).Where("task_id = ?", taskId)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	result := query.UpdateColumn("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if version != 0 {
+			return service.ErrPreconditionFailed
+		}
+		return service.ErrNotFound
+	}
+	return nil
+}
+
+// translateError maps constraint violations to API errors.
+func translateError(err error) error {
+	c, ok := db.AsConstraintError(err)
+	if !ok {
+		return err
+	}
+
+	if c.Constraint == db.FKTasksOwner {
+		return service.ErrTaskOwnerNotFound
+	}
+	return fmt.Errorf("%w: %s", service.ErrConflict, c.Error())
+}
@@ -1,14 +1,18 @@
 package task
 
-import "net/http"
+import (
+	"net/http"
+	"time_tracker/api/auth"
+)
 
-func AddRoutes(router *http.ServeMux) {
-	router.HandleFunc("POST /api/v1/task", CreateTaskHandler)
-	router.HandleFunc("GET /api/v1/task/{uuid}", ReadOneTaskHandler)
-	router.HandleFunc("GET /api/v1/tasks/{user_uuid}", ReadManyTaskHandler)
-	router.HandleFunc("GET /api/v1/tasks/summary/{user_uuid}", SummaryHandler)
-	router.HandleFunc("PUT /api/v1/task/{uuid}", UpdateTaskHandler)
-	router.HandleFunc("DELETE /api/v1/task/{uuid}", DeleteTaskHandler)
-	router.HandleFunc("GET /api/v1/task/start/{uuid}", StartTaskHandler)
-	router.HandleFunc("GET /api/v1/task/finish/{uuid}", FinishTaskHandler)
+func (h *Handler) AddRoutes(router *http.ServeMux) {
+	router.HandleFunc("POST /api/v1/task", auth.Scoped(auth.ScopeTasksWrite, h.CreateTaskHandler))
+	router.HandleFunc("GET /api/v1/task/{uuid}", auth.Scoped(auth.ScopeTasksRead, h.ReadOneTaskHandler))
+	router.HandleFunc("GET /api/v1/tasks/{user_uuid}", auth.Scoped(auth.ScopeTasksRead, h.ReadManyTaskHandler))
+	router.HandleFunc("GET /api/v1/tasks/summary/{user_uuid}", auth.Scoped(auth.ScopeReportsRead, h.SummaryHandler))
+	router.HandleFunc("PUT /api/v1/task/{uuid}", auth.Scoped(auth.ScopeTasksWrite, h.UpdateTaskHandler))
+	router.HandleFunc("DELETE /api/v1/task/{uuid}", auth.Scoped(auth.ScopeTasksWrite, h.DeleteTaskHandler))
+	router.HandleFunc("GET /api/v1/task/start/{uuid}", auth.Scoped(auth.ScopeTasksWrite, h.StartTaskHandler))
+	router.HandleFunc("GET /api/v1/task/finish/{uuid}", auth.Scoped(auth.ScopeTasksWrite, h.FinishTaskHandler))
+	router.HandleFunc("POST /api/v1/tasks/batch", auth.Scoped(auth.ScopeTasksWrite, h.BatchTaskHandler))
 }
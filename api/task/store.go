@@ -0,0 +1,43 @@
+package task
+
+import (
+	"gorm.io/gorm"
+	"time_tracker/api/user"
+)
+
+// GormStore runs user and task changes in one database transaction.
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Transaction(fn func(users user.UserRepository, tasks user.OwnedTasks) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(user.NewGormRepository(tx), NewGormRepository(tx))
+	})
+}
+
+// MemoryStore combines memory repositories for tests. Transactions are
+// serialized, a failed one restores both users and tasks.
+type MemoryStore struct {
+	users *user.MemoryRepository
+	tasks *MemoryRepository
+}
+
+func NewMemoryStore(users *user.MemoryRepository, tasks *MemoryRepository) *MemoryStore {
+	return &MemoryStore{users: users, tasks: tasks}
+}
+
+func (s *MemoryStore) Transaction(fn func(users user.UserRepository, tasks user.OwnedTasks) error) error {
+	return s.tasks.Transaction(func(tasks TaskRepository) error {
+		restore := s.users.Snapshot()
+		err := fn(s.users, tasks)
+		if err != nil {
+			restore()
+		}
+		return err
+	})
+}
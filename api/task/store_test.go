@@ -0,0 +1,77 @@
+package task_test
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+	"time_tracker/api/task"
+	"time_tracker/api/user"
+)
+
+func TestMemoryStoreTransaction(t *testing.T) {
+	users := user.NewMemoryRepository()
+	tasks := task.NewMemoryRepository()
+	store := task.NewMemoryStore(users, tasks)
+
+	org := user.Organization{OrganizationId: uuid.New(), Name: "Acme"}
+	usr := user.FullUser{UserId: uuid.New(), OrganizationId: org.OrganizationId, Login: "member"}
+	tsk := task.FullTask{TaskId: uuid.New(), OwnerId: usr.UserId, OrganizationId: org.OrganizationId, Title: "Report"}
+	for _, err := range []error{users.CreateOrganization(&org), users.Create(&usr), tasks.Create(&tsk)} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// deletes the user with the tasks and creates an organization
+	other := user.Organization{OrganizationId: uuid.New(), Name: "Other"}
+	deleteUser := func(fail error) error {
+		return store.Transaction(func(users user.UserRepository, tasks user.OwnedTasks) error {
+			err := tasks.DeleteByOwner(usr.UserId)
+			if err != nil {
+				return err
+			}
+			err = users.Delete(org.OrganizationId, usr.UserId, 0)
+			if err != nil {
+				return err
+			}
+			err = users.CreateOrganization(&other)
+			if err != nil {
+				return err
+			}
+			return fail
+		})
+	}
+
+	failed := errors.New("failed")
+	err := deleteUser(failed)
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+
+	_, err = users.ReadOne(org.OrganizationId, usr.UserId)
+	if err != nil {
+		t.Fatal("user delete not rolled back: ", err)
+	}
+	_, err = users.ReadOrganization(other.OrganizationId)
+	if err == nil {
+		t.Fatal("organization create not rolled back")
+	}
+	count, err := tasks.CountByOwner(usr.UserId)
+	if err != nil || count != 1 {
+		t.Fatalf("tasks delete not rolled back: %d, %v", count, err)
+	}
+
+	err = deleteUser(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = users.ReadOne(org.OrganizationId, usr.UserId)
+	if err == nil {
+		t.Fatal("user not deleted")
+	}
+	_, err = users.ReadOrganization(other.OrganizationId)
+	if err != nil {
+		t.Fatal("organization not created: ", err)
+	}
+}
@@ -0,0 +1,356 @@
+package trash
+
+import (
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time_tracker/api/service"
+	"time_tracker/api/task"
+	"time_tracker/api/user"
+)
+
+// Handler serves admin endpoints for soft-deleted users and tasks.
+type Handler struct {
+	Users user.UserRepository
+	Tasks task.TaskRepository
+	Store user.Store
+}
+
+func NewHandler(users user.UserRepository, tasks task.TaskRepository, store user.Store) *Handler {
+	return &Handler{Users: users, Tasks: tasks, Store: store}
+}
+
+// ReadDeletedUsersHandler godoc
+//
+//	@Summary		Get deleted users
+//	@Description	Get soft-deleted users of the organization, most recently deleted first. Admins only.
+//	@Tags			Trash
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{array}		DeletedUser
+//	@Failure		401	{object}	service.ErrorResponse
+//	@Failure		403	{object}	service.ErrorResponse
+//	@Failure		500	{object}	service.ErrorResponse
+//	@Router			/trash/users [get]
+func (h *Handler) ReadDeletedUsersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	if !actor.IsAdmin() {
+		e.Error403(service.ErrForbidden)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	users, err := h.Users.ReadDeleted(actor.OrganizationId)
+	if err != nil {
+		e.DBError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	service.ServerResponse(w, deletedUsers(users))
+	log.Info("Deleted users read successfully")
+}
+
+// RestoreUserHandler godoc
+//
+//	@Summary		Restore user
+//	@Description	Restore soft-deleted user by UUID. Fails if the passport or login was taken by another user meanwhile.
+//	@Description	With tasks=restore the user's deleted tasks, such as those deleted together with the user, are restored too. Admins only.
+//	@Tags			Trash
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			uuid	path		string	true	"Provide user's uuid"
+//	@Param			tasks	query		string	false	"Tasks policy"	Enums(keep, restore)
+//	@Success		200		{object}	service.OkResponse
+//	@Failure		400		{object}	service.ErrorResponse
+//	@Failure		401		{object}	service.ErrorResponse
+//	@Failure		403		{object}	service.ErrorResponse
+//	@Failure		404		{object}	service.ErrorResponse
+//	@Failure		409		{object}	service.ErrorResponse
+//	@Failure		500		{object}	service.ErrorResponse
+//	@Router			/trash/users/{uuid}/restore [post]
+func (h *Handler) RestoreUserHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	if !actor.IsAdmin() {
+		e.Error403(service.ErrForbidden)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	userId, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		e.UuidParseError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	restoreTasks, err := restoreTasksParam(r.URL.Query())
+	if err != nil {
+		e.ValidationError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	err = restoreUser(h.Store, actor.OrganizationId, userId, restoreTasks)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	msg := "User restored successfully"
+
+	service.ServerResponse(w, service.OkResponse{
+		Code:    http.StatusOK,
+		Message: msg,
+		Data:    userId,
+	})
+	log.Info(msg)
+}
+
+// PurgeUserHandler godoc
+//
+//	@Summary		Purge user
+//	@Description	Permanently delete soft-deleted user by UUID together with the user's deleted tasks.
+//	@Description	Users that still own tasks can't be purged. Admins only.
+//	@Tags			Trash
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			uuid	path		string	true	"Provide user's uuid"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	service.ErrorResponse
+//	@Failure		401		{object}	service.ErrorResponse
+//	@Failure		403		{object}	service.ErrorResponse
+//	@Failure		404		{object}	service.ErrorResponse
+//	@Failure		409		{object}	service.ErrorResponse
+//	@Failure		500		{object}	service.ErrorResponse
+//	@Router			/trash/users/{uuid} [delete]
+func (h *Handler) PurgeUserHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	if !actor.IsAdmin() {
+		e.Error403(service.ErrForbidden)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	userId, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		e.UuidParseError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	err = purgeUser(h.Store, actor.OrganizationId, userId)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	msg := "User purged successfully"
+
+	service.ServerResponse(w, service.OkResponse{
+		Code:    http.StatusNoContent,
+		Message: msg,
+		Data:    "",
+	})
+	log.Info(msg)
+}
+
+// ReadDeletedTasksHandler godoc
+//
+//	@Summary		Get deleted tasks
+//	@Description	Get soft-deleted tasks of the organization, most recently deleted first. Admins only.
+//	@Tags			Trash
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{array}		DeletedTask
+//	@Failure		401	{object}	service.ErrorResponse
+//	@Failure		403	{object}	service.ErrorResponse
+//	@Failure		500	{object}	service.ErrorResponse
+//	@Router			/trash/tasks [get]
+func (h *Handler) ReadDeletedTasksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	if !actor.IsAdmin() {
+		e.Error403(service.ErrForbidden)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	tasks, err := h.Tasks.ReadDeleted(actor.OrganizationId)
+	if err != nil {
+		e.DBError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	service.ServerResponse(w, deletedTasks(tasks))
+	log.Info("Deleted tasks read successfully")
+}
+
+// RestoreTaskHandler godoc
+//
+//	@Summary		Restore task
+//	@Description	Restore soft-deleted task by UUID. The owner must not be deleted. Admins only.
+//	@Tags			Trash
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			uuid	path		string	true	"Provide task's uuid"
+//	@Success		200		{object}	service.OkResponse
+//	@Failure		400		{object}	service.ErrorResponse
+//	@Failure		401		{object}	service.ErrorResponse
+//	@Failure		403		{object}	service.ErrorResponse
+//	@Failure		404		{object}	service.ErrorResponse
+//	@Failure		500		{object}	service.ErrorResponse
+//	@Router			/trash/tasks/{uuid}/restore [post]
+func (h *Handler) RestoreTaskHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	if !actor.IsAdmin() {
+		e.Error403(service.ErrForbidden)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	taskId, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		e.UuidParseError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	err = restoreTask(h.Tasks, h.Users, actor.OrganizationId, taskId)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	msg := "Task restored successfully"
+
+	service.ServerResponse(w, service.OkResponse{
+		Code:    http.StatusOK,
+		Message: msg,
+		Data:    taskId,
+	})
+	log.Info(msg)
+}
+
+// PurgeTaskHandler godoc
+//
+//	@Summary		Purge task
+//	@Description	Permanently delete soft-deleted task by UUID. Admins only.
+//	@Tags			Trash
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			uuid	path		string	true	"Provide task's uuid"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	service.ErrorResponse
+//	@Failure		401		{object}	service.ErrorResponse
+//	@Failure		403		{object}	service.ErrorResponse
+//	@Failure		404		{object}	service.ErrorResponse
+//	@Failure		500		{object}	service.ErrorResponse
+//	@Router			/trash/tasks/{uuid} [delete]
+func (h *Handler) PurgeTaskHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	actor, err := user.CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	if !actor.IsAdmin() {
+		e.Error403(service.ErrForbidden)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	taskId, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		e.UuidParseError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	_, err = h.Tasks.ReadDeletedOne(actor.OrganizationId, taskId)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	err = h.Tasks.Purge(taskId)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	msg := "Task purged successfully"
+
+	service.ServerResponse(w, service.OkResponse{
+		Code:    http.StatusNoContent,
+		Message: msg,
+		Data:    "",
+	})
+	log.Info(msg)
+}
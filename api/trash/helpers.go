@@ -0,0 +1,92 @@
+package trash
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"net/url"
+	"time_tracker/api/service"
+	"time_tracker/api/task"
+	"time_tracker/api/user"
+	"time_tracker/api/validation"
+)
+
+// purgeUser permanently deletes the soft-deleted user together with their
+// soft-deleted tasks. Users that still own live tasks are kept.
+func purgeUser(store user.Store, organizationId, userId uuid.UUID) error {
+	return store.Transaction(func(users user.UserRepository, tasks user.OwnedTasks) error {
+		count, err := tasks.CountByOwner(userId)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return service.ErrUserHasTasks
+		}
+
+		err = tasks.PurgeByOwner(userId)
+		if err != nil {
+			return err
+		}
+		return users.Purge(organizationId, userId)
+	})
+}
+
+// restoreTasksParam reads the tasks query parameter of the user restore,
+// tasks defaults to keep.
+func restoreTasksParam(queryParams url.Values) (bool, error) {
+	tasks := queryParams.Get("tasks")
+	if tasks == "" {
+		tasks = TasksKeep
+	}
+
+	v := validation.New()
+	v.OneOf("tasks", tasks, TasksKeep, TasksRestore)
+	return tasks == TasksRestore, v.Err()
+}
+
+// restoreUser restores the soft-deleted user, with restoreTasks also their
+// soft-deleted tasks, such as those deleted with tasks=cascade, in the same
+// transaction.
+func restoreUser(store user.Store, organizationId, userId uuid.UUID, restoreTasks bool) error {
+	return store.Transaction(func(users user.UserRepository, tasks user.OwnedTasks) error {
+		err := users.Restore(organizationId, userId)
+		if err != nil || !restoreTasks {
+			return err
+		}
+		return tasks.RestoreByOwner(userId)
+	})
+}
+
+// restoreTask restores the soft-deleted task if its owner is not deleted.
+func restoreTask(tasks task.TaskRepository, users user.UserRepository, organizationId, taskId uuid.UUID) error {
+	tsk, err := tasks.ReadDeletedOne(organizationId, taskId)
+	if err != nil {
+		return err
+	}
+
+	_, err = users.ReadOne(organizationId, tsk.OwnerId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return service.ErrTaskOwnerNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return tasks.Restore(taskId)
+}
+
+func deletedUsers(users []user.FullUser) []DeletedUser {
+	out := make([]DeletedUser, len(users))
+	for i, usr := range users {
+		out[i] = DeletedUser{FullUser: usr, DeletedAt: usr.DeletedAt.Time}
+	}
+	return out
+}
+
+func deletedTasks(tasks []task.FullTask) []DeletedTask {
+	out := make([]DeletedTask, len(tasks))
+	for i, tsk := range tasks {
+		out[i] = DeletedTask{FullTask: tsk, DeletedAt: tsk.DeletedAt.Time}
+	}
+	return out
+}
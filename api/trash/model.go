@@ -0,0 +1,22 @@
+package trash
+
+import (
+	"time"
+	"time_tracker/api/task"
+	"time_tracker/api/user"
+)
+
+const (
+	TasksKeep    = "keep"
+	TasksRestore = "restore"
+)
+
+type DeletedUser struct {
+	user.FullUser
+	DeletedAt time.Time `json:"deletedAt" extensions:"x-order=14"`
+}
+
+type DeletedTask struct {
+	task.FullTask
+	DeletedAt time.Time `json:"deleted_at" extensions:"x-order=8"`
+}
@@ -0,0 +1,92 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"time"
+	"time_tracker/api/task"
+	"time_tracker/api/user"
+)
+
+// errUserKept rolls back the purge of a user that still owns tasks.
+var errUserKept = errors.New("user still owns tasks")
+
+// Retention periodically purges users and tasks soft-deleted longer than
+// Period ago. Users that still own tasks, even deleted ones, are kept
+// until those tasks expire too.
+type Retention struct {
+	Users    user.UserRepository
+	Tasks    task.TaskRepository
+	Store    user.Store
+	Period   time.Duration
+	Interval time.Duration
+}
+
+func NewRetention(users user.UserRepository, tasks task.TaskRepository, store user.Store, period, interval time.Duration) *Retention {
+	return &Retention{Users: users, Tasks: tasks, Store: store, Period: period, Interval: interval}
+}
+
+// Run purges expired records every Interval until ctx is cancelled.
+func (r *Retention) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	log.WithField("period", r.Period).Info("Trash retention started")
+	for {
+		r.Purge(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge permanently deletes records soft-deleted before now minus Period.
+func (r *Retention) Purge(now time.Time) {
+	cutoff := now.Add(-r.Period)
+
+	tasks, err := r.Tasks.PurgeDeletedBefore(cutoff)
+	if err != nil {
+		log.Error("Trash retention: purge tasks: ", err)
+		return
+	}
+
+	expired, err := r.Users.ReadDeletedBefore(cutoff)
+	if err != nil {
+		log.Error("Trash retention: read users: ", err)
+		return
+	}
+
+	var users int
+	for _, u := range expired {
+		err = r.Store.Transaction(func(users user.UserRepository, tasks user.OwnedTasks) error {
+			live, err := tasks.CountByOwner(u.UserId)
+			if err != nil {
+				return err
+			}
+			deleted, err := tasks.CountDeletedByOwner(u.UserId)
+			if err != nil {
+				return err
+			}
+			if live+deleted > 0 {
+				return errUserKept
+			}
+			return users.Purge(u.OrganizationId, u.UserId)
+		})
+		if errors.Is(err, errUserKept) {
+			continue
+		}
+		if err != nil {
+			log.WithField("user", u.UserId).Error("Trash retention: purge user: ", err)
+			continue
+		}
+		users++
+	}
+
+	if tasks > 0 || users > 0 {
+		log.WithFields(log.Fields{"tasks": tasks, "users": users}).Info("Trash retention purged expired records")
+	}
+}
@@ -0,0 +1,110 @@
+package trash_test
+
+import (
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"testing"
+	"time"
+	"time_tracker/api/task"
+	"time_tracker/api/trash"
+	"time_tracker/api/user"
+)
+
+var organizationId = uuid.New()
+
+type testTrash struct {
+	t     *testing.T
+	users *user.MemoryRepository
+	tasks *task.MemoryRepository
+}
+
+func newTestTrash(t *testing.T) *testTrash {
+	log.SetOutput(io.Discard)
+	return &testTrash{t: t, users: user.NewMemoryRepository(), tasks: task.NewMemoryRepository()}
+}
+
+// deletedUser creates a soft-deleted user with live and deleted tasks.
+func (tr *testTrash) deletedUser(live, deleted int) uuid.UUID {
+	tr.t.Helper()
+
+	usr := user.FullUser{UserId: uuid.New(), OrganizationId: organizationId, Login: uuid.NewString()}
+	err := tr.users.Create(&usr)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+
+	for i := 0; i < live+deleted; i++ {
+		tsk := task.FullTask{TaskId: uuid.New(), OwnerId: usr.UserId, OrganizationId: organizationId, Title: "Report"}
+		err = tr.tasks.Create(&tsk)
+		if err == nil && i >= live {
+			err = tr.tasks.Delete(tsk.TaskId, 0)
+		}
+		if err != nil {
+			tr.t.Fatal(err)
+		}
+	}
+
+	err = tr.users.Delete(organizationId, usr.UserId, 0)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return usr.UserId
+}
+
+// purged reports whether the user is gone from the trash.
+func (tr *testTrash) purged(userId uuid.UUID) bool {
+	tr.t.Helper()
+
+	deleted, err := tr.users.ReadDeleted(organizationId)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	for _, usr := range deleted {
+		if usr.UserId == userId {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRetentionCutoff(t *testing.T) {
+	tr := newTestTrash(t)
+	retention := trash.NewRetention(tr.users, tr.tasks, task.NewMemoryStore(tr.users, tr.tasks), time.Hour, time.Hour)
+	userId := tr.deletedUser(0, 1)
+
+	// deleted less than a period ago
+	retention.Purge(time.Now().Add(59 * time.Minute))
+	if tr.purged(userId) {
+		t.Fatal("expected the user kept before the cutoff")
+	}
+	if n, _ := tr.tasks.CountDeletedByOwner(userId); n != 1 {
+		t.Fatalf("expected the deleted task kept before the cutoff, got %d", n)
+	}
+
+	retention.Purge(time.Now().Add(61 * time.Minute))
+	if !tr.purged(userId) {
+		t.Fatal("expected the user purged after the cutoff")
+	}
+	if n, _ := tr.tasks.CountDeletedByOwner(userId); n != 0 {
+		t.Fatalf("expected the deleted task purged after the cutoff, got %d", n)
+	}
+}
+
+func TestRetentionKeepsOwners(t *testing.T) {
+	tr := newTestTrash(t)
+	retention := trash.NewRetention(tr.users, tr.tasks, task.NewMemoryStore(tr.users, tr.tasks), time.Hour, time.Hour)
+	owner := tr.deletedUser(1, 0)
+	empty := tr.deletedUser(0, 0)
+
+	retention.Purge(time.Now().Add(2 * time.Hour))
+	if tr.purged(owner) {
+		t.Fatal("expected the user owning a live task kept")
+	}
+	if !tr.purged(empty) {
+		t.Fatal("expected the user without tasks purged")
+	}
+	if n, _ := tr.tasks.CountByOwner(owner); n != 1 {
+		t.Fatalf("expected the live task kept, got %d", n)
+	}
+}
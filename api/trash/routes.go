@@ -0,0 +1,15 @@
+package trash
+
+import (
+	"net/http"
+	"time_tracker/api/auth"
+)
+
+func (h *Handler) AddRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /api/v1/trash/users", auth.Scoped(auth.ScopeUsersRead, h.ReadDeletedUsersHandler))
+	router.HandleFunc("POST /api/v1/trash/users/{uuid}/restore", auth.Scoped(auth.ScopeUsersWrite, h.RestoreUserHandler))
+	router.HandleFunc("DELETE /api/v1/trash/users/{uuid}", auth.Scoped(auth.ScopeUsersWrite, h.PurgeUserHandler))
+	router.HandleFunc("GET /api/v1/trash/tasks", auth.Scoped(auth.ScopeTasksRead, h.ReadDeletedTasksHandler))
+	router.HandleFunc("POST /api/v1/trash/tasks/{uuid}/restore", auth.Scoped(auth.ScopeTasksWrite, h.RestoreTaskHandler))
+	router.HandleFunc("DELETE /api/v1/trash/tasks/{uuid}", auth.Scoped(auth.ScopeTasksWrite, h.PurgeTaskHandler))
+}
@@ -0,0 +1,59 @@
+package user
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"time_tracker/api/auth"
+)
+
+const (
+	RoleAdmin   = "admin"
+	RoleManager = "manager"
+	RoleMember  = "member"
+)
+
+// CurrentUser loads the authenticated user making the request.
+func CurrentUser(r *http.Request, users UserRepository) (FullUser, error) {
+	userId, ok := auth.UserId(r)
+	if !ok {
+		return FullUser{}, errors.New("user can't be determined")
+	}
+
+	usr, err := users.ReadById(userId)
+	if err != nil {
+		return FullUser{}, errors.New("user not found")
+	}
+	return usr, nil
+}
+
+func (f *FullUser) IsAdmin() bool {
+	return f.Role == RoleAdmin
+}
+
+// CanManageUsers reports whether f may change roles, managers and delete users.
+func (f *FullUser) CanManageUsers() bool {
+	return f.IsAdmin()
+}
+
+// CanReadUser allows admins, the user himself and his manager.
+func (f *FullUser) CanReadUser(target *FullUser) bool {
+	if f.OrganizationId != target.OrganizationId {
+		return false
+	}
+	return f.IsAdmin() || f.UserId == target.UserId || f.manages(target)
+}
+
+// CanAccessTasksOf allows only admins and the owner to see and modify tasks.
+func (f *FullUser) CanAccessTasksOf(ownerId uuid.UUID) bool {
+	return f.IsAdmin() || f.UserId == ownerId
+}
+
+// CanReadReportsOf additionally allows managers to see their team's reports.
+func (f *FullUser) CanReadReportsOf(owner *FullUser) bool {
+	return f.CanReadUser(owner)
+}
+
+func (f *FullUser) manages(target *FullUser) bool {
+	return f.Role == RoleManager && target.ManagerId == f.UserId
+}
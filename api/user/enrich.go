@@ -0,0 +1,206 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/service"
+)
+
+// EnrichmentQueue completes users created in the pending_enrichment state.
+// Workers call the provider with exponential backoff and store the result,
+// or mark the user failed after Attempts tries. Pending users are read from
+// the repository every Interval, so users left by a previous run or not
+// fitting into a full queue are picked up with the data submitted on creation.
+type EnrichmentQueue struct {
+	Users    UserRepository
+	Provider enrichment.Provider
+	Workers  int
+	Attempts int
+	Backoff  time.Duration
+	Interval time.Duration
+
+	jobs chan FullUser
+
+	mu     sync.Mutex
+	queued map[uuid.UUID]bool
+}
+
+// enrichMaxBackoff caps the delay between attempts.
+const enrichMaxBackoff = 5 * time.Minute
+
+var errQueueFull = errors.New("enrichment queue is full")
+
+func NewEnrichmentQueue(users UserRepository, provider enrichment.Provider, workers, attempts int, backoff, interval time.Duration) *EnrichmentQueue {
+	return &EnrichmentQueue{
+		Users:    users,
+		Provider: provider,
+		Workers:  workers,
+		Attempts: attempts,
+		Backoff:  backoff,
+		Interval: interval,
+		jobs:     make(chan FullUser, 1024),
+		queued:   map[uuid.UUID]bool{},
+	}
+}
+
+// Enqueue schedules enrichment of the pending user without blocking, a user
+// not fitting into a full queue stays pending until the next poll.
+func (q *EnrichmentQueue) Enqueue(usr FullUser) {
+	_, err := q.push(usr)
+	if err != nil {
+		log.WithField("user", usr.UserId).Warn(err, ", user stays pending until the next poll")
+	}
+}
+
+// push queues the user unless it is queued already and reports whether it was.
+func (q *EnrichmentQueue) push(usr FullUser) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queued[usr.UserId] {
+		return false, nil
+	}
+
+	select {
+	case q.jobs <- usr:
+		q.queued[usr.UserId] = true
+		return true, nil
+	default:
+		return false, errQueueFull
+	}
+}
+
+// Run starts the workers, polls pending users every Interval and blocks
+// until ctx is cancelled and the workers stop.
+func (q *EnrichmentQueue) Run(ctx context.Context) {
+	var workers sync.WaitGroup
+	for i := 0; i < q.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			q.work(ctx)
+		}()
+	}
+	defer workers.Wait()
+
+	ticker := time.NewTicker(q.Interval)
+	defer ticker.Stop()
+
+	log.WithFields(log.Fields{"workers": q.Workers, "interval": q.Interval}).Info("Enrichment queue started")
+	for {
+		q.poll()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll enqueues pending users which aren't queued yet.
+func (q *EnrichmentQueue) poll() {
+	pending, err := q.Users.ReadPending()
+	if err != nil {
+		log.Error("Enrichment: read pending users: ", err)
+		return
+	}
+
+	var enqueued int
+	for i, usr := range pending {
+		ok, err := q.push(usr)
+		if err != nil {
+			log.WithField("users", len(pending)-i).Warn(err, ", users stay pending until the next poll")
+			break
+		}
+		if ok {
+			enqueued++
+		}
+	}
+	if enqueued > 0 {
+		log.WithField("users", enqueued).Info("Enrichment: pending users enqueued")
+	}
+}
+
+func (q *EnrichmentQueue) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case usr := <-q.jobs:
+			q.enrich(ctx, usr)
+
+			q.mu.Lock()
+			delete(q.queued, usr.UserId)
+			q.mu.Unlock()
+		}
+	}
+}
+
+func (q *EnrichmentQueue) enrich(ctx context.Context, usr FullUser) {
+	req := enrichment.Request{
+		PassportSerie:  usr.PassportSerie,
+		PassportNumber: usr.PassportNumber,
+	}
+	if usr.SubmittedPerson != nil {
+		req.Submitted = *usr.SubmittedPerson
+	}
+	logger := log.WithField("user", usr.UserId)
+
+	var result EnrichmentResult
+	for attempt := 1; ; attempt++ {
+		person, err := q.Provider.Enrich(ctx, req)
+		if err == nil {
+			err = person.ValidateRequiredFields()
+			if err == nil {
+				result = EnrichmentResult{Status: EnrichmentComplete, Person: person}
+			} else {
+				result = EnrichmentResult{Status: EnrichmentFailed, Error: err.Error()}
+			}
+			break
+		}
+
+		if ctx.Err() != nil {
+			// the user stays pending and is picked up on the next start
+			return
+		}
+
+		if errors.Is(err, service.ErrPersonNotFound) || attempt >= q.Attempts {
+			result = EnrichmentResult{Status: EnrichmentFailed, Error: err.Error()}
+			break
+		}
+
+		delay := q.Backoff << (attempt - 1)
+		if delay > enrichMaxBackoff || delay <= 0 {
+			delay = enrichMaxBackoff
+		}
+		logger.WithFields(log.Fields{"attempt": attempt, "delay": delay}).Warn("Enrichment failed, retrying: ", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+
+	err := q.Users.SetEnrichment(usr.OrganizationId, usr.UserId, result)
+	if errors.Is(err, service.ErrNotFound) {
+		logger.Info("User is deleted or already enriched, enrichment result dropped")
+		return
+	}
+	if err != nil {
+		logger.Error("Enrichment result not saved: ", err)
+		return
+	}
+
+	if result.Status == EnrichmentComplete {
+		logger.Info("User enriched")
+	} else {
+		logger.Warn("User enrichment failed: ", result.Error)
+	}
+}
@@ -0,0 +1,195 @@
+package user_test
+
+import (
+	"context"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+	"time_tracker/api/apitest"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/service"
+	"time_tracker/api/task"
+	"time_tracker/api/user"
+)
+
+var submitted = enrichment.Person{Name: "Ivan", Surname: "Ivanov", Address: "Moscow"}
+
+// testProvider fails the first failures calls with err, then returns the
+// submitted person, and records when it was called.
+type testProvider struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	calls    []time.Time
+}
+
+func (p *testProvider) Enrich(ctx context.Context, req enrichment.Request) (enrichment.Person, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls = append(p.calls, time.Now())
+	if len(p.calls) <= p.failures {
+		return enrichment.Person{}, p.err
+	}
+	return req.Submitted, nil
+}
+
+func (p *testProvider) Calls() []time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]time.Time(nil), p.calls...)
+}
+
+// startQueue runs the queue until the test ends.
+func startQueue(t *testing.T, q *user.EnrichmentQueue) {
+	t.Helper()
+	log.SetOutput(io.Discard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func createPending(t *testing.T, users *user.MemoryRepository) user.FullUser {
+	t.Helper()
+
+	person := submitted
+	usr := user.FullUser{UserId: uuid.New(), OrganizationId: uuid.New(), Login: uuid.NewString(),
+		EnrichmentStatus: user.EnrichmentPending, SubmittedPerson: &person}
+	err := users.Create(&usr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return usr
+}
+
+// waitEnriched waits until the user leaves the pending state.
+func waitEnriched(t *testing.T, users *user.MemoryRepository, usr user.FullUser) user.FullUser {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		stored, err := users.ReadOne(usr.OrganizationId, usr.UserId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.EnrichmentStatus != user.EnrichmentPending {
+			return stored
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("user is still pending")
+	return user.FullUser{}
+}
+
+func TestEnrichmentQueueRetries(t *testing.T) {
+	backoff := 20 * time.Millisecond
+	users := user.NewMemoryRepository()
+	provider := &testProvider{failures: 2, err: service.ErrExternalAPI}
+	usr := createPending(t, users)
+
+	startQueue(t, user.NewEnrichmentQueue(users, provider, 1, 5, backoff, time.Hour))
+
+	stored := waitEnriched(t, users, usr)
+	if stored.EnrichmentStatus != user.EnrichmentComplete || stored.Name != submitted.Name || stored.Address != submitted.Address {
+		t.Fatalf("expected the submitted person stored, got %s %+v", stored.EnrichmentStatus, stored)
+	}
+	if stored.SubmittedPerson != nil {
+		t.Fatal("expected the submitted person dropped after enrichment")
+	}
+
+	calls := provider.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 calls, got %d", len(calls))
+	}
+	for i := 1; i < len(calls); i++ {
+		delay := backoff << (i - 1)
+		if gap := calls[i].Sub(calls[i-1]); gap < delay {
+			t.Fatalf("expected retry %d after at least %v, got %v", i, delay, gap)
+		}
+	}
+}
+
+func TestEnrichmentQueueFails(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		calls int
+	}{
+		{"attempts exhausted", service.ErrExternalAPI, 3},
+		{"person not found", service.ErrPersonNotFound, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := user.NewMemoryRepository()
+			provider := &testProvider{failures: 100, err: tt.err}
+			usr := createPending(t, users)
+
+			startQueue(t, user.NewEnrichmentQueue(users, provider, 1, 3, time.Millisecond, time.Hour))
+
+			stored := waitEnriched(t, users, usr)
+			if stored.EnrichmentStatus != user.EnrichmentFailed || stored.EnrichmentError != tt.err.Error() {
+				t.Fatalf("expected failure %q, got %s %q", tt.err, stored.EnrichmentStatus, stored.EnrichmentError)
+			}
+			if stored.Name != "" {
+				t.Fatalf("expected no personal data of a failed user, got %q", stored.Name)
+			}
+			if n := len(provider.Calls()); n != tt.calls {
+				t.Fatalf("expected %d calls, got %d", tt.calls, n)
+			}
+		})
+	}
+}
+
+func TestEnrichmentQueuePollsPending(t *testing.T) {
+	users := user.NewMemoryRepository()
+	startQueue(t, user.NewEnrichmentQueue(users, enrichment.NoopProvider{}, 2, 3, time.Millisecond, 10*time.Millisecond))
+
+	// users left pending by a previous run or a full queue are never
+	// enqueued, the poll picks them up with the data submitted on creation
+	for i := 0; i < 3; i++ {
+		usr := createPending(t, users)
+
+		stored := waitEnriched(t, users, usr)
+		if stored.EnrichmentStatus != user.EnrichmentComplete || stored.Surname != submitted.Surname {
+			t.Fatalf("expected the submitted person stored, got %s %+v", stored.EnrichmentStatus, stored)
+		}
+	}
+}
+
+func TestCreateUserHandlerAsync(t *testing.T) {
+	s := newTestServer(t)
+	queue := user.NewEnrichmentQueue(s.users, enrichment.NoopProvider{}, 1, 3, time.Millisecond, 10*time.Millisecond)
+	router := http.NewServeMux()
+	user.NewHandler(s.users, task.NewMemoryStore(s.users, s.tasks), s.keys, enrichment.NoopProvider{}, queue).AddRoutes(router)
+	s.handler = router
+
+	// the queue isn't running, as if the server stopped right after the request
+	status, code := s.do(uuid.Nil, "POST", "/user", body(t, apitest.NewMember("member", "2000 200000", s.org.JoinCode)))
+	expect(t, status, code, http.StatusAccepted, "")
+
+	usr, err := s.users.ReadByLogin("member")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usr.EnrichmentStatus != user.EnrichmentPending || usr.SubmittedPerson == nil || usr.SubmittedPerson.Name != "Petr" {
+		t.Fatalf("expected pending user with the submitted person, got %s %+v", usr.EnrichmentStatus, usr.SubmittedPerson)
+	}
+
+	startQueue(t, queue)
+	stored := waitEnriched(t, s.users, usr)
+	if stored.EnrichmentStatus != user.EnrichmentComplete || stored.Name != "Petr" || stored.Address != "Kazan" {
+		t.Fatalf("expected the submitted person stored, got %s %+v", stored.EnrichmentStatus, stored)
+	}
+}
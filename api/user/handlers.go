@@ -1,28 +1,58 @@
 package user
 
 import (
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 	"io"
 	"net/http"
-	"strconv"
+	"time_tracker/api/auth"
+	"time_tracker/api/enrichment"
 	"time_tracker/api/service"
+	"time_tracker/api/validation"
 )
 
+// Handler serves user, organization and login endpoints. Store is used
+// where users and their tasks change together, Enricher provides personal
+// data of new users. With Queue set new users are enriched in the background.
+// API keys of deleted users are revoked in Keys.
+type Handler struct {
+	Users    UserRepository
+	Store    Store
+	Keys     auth.APIKeyRepository
+	Enricher enrichment.Provider
+	Queue    *EnrichmentQueue
+}
+
+func NewHandler(users UserRepository, store Store, keys auth.APIKeyRepository, enricher enrichment.Provider, queue *EnrichmentQueue) *Handler {
+	return &Handler{Users: users, Store: store, Keys: keys, Enricher: enricher, Queue: queue}
+}
+
 // CreateUserHandler godoc
 //
 //	@Summary		Create user
-//	@Description	Create user by passport serie and number
+//	@Description	Create user by passport serie and number. Login and password are used to obtain access tokens.
+//	@Description	User joins the organization by its join code. Passports are unique within organization.
+//	@Description	New users get the member role, the admin of the organization is created together with it.
+//	@Description	Name, surname, patronymic and address are provided by the enrichment provider, the ones in the body are used only by the "none" provider.
+//	@Description	In async enrichment mode the user is created in the pending_enrichment state and 202 is returned, enrichmentStatus of the user shows the outcome.
 //	@Tags			User
 //	@Accept			json
 //	@Produce		json
-//	@Param			New	user		body	NewUser	true	"Provide passport serie and number in format '1234 567890'"
-//	@Success		200	{object}	FullUser
+//	@Param			New	user		body	NewUser	true	"Provide passport serie and number in format '1234 567890', login, password and organization join code"
+//	@Success		201	{object}	FullUser
+//	@Success		202	{object}	FullUser
 //	@Failure		400	{object}	service.ErrorResponse
+//	@Failure		404	{object}	service.ErrorResponse
+//	@Failure		409	{object}	service.ErrorResponse
+//	@Failure		413	{object}	service.ErrorResponse
 //	@Failure		500	{object}	service.ErrorResponse
+//	@Failure		502	{object}	service.ErrorResponse
+//	@Failure		503	{object}	service.ErrorResponse
 //	@Router			/user [post]
-func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
@@ -44,54 +74,99 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	serie, number, err := validatePassportNumber(newUsr.PassportNumber)
+	serie, number, err := newUsr.validate()
 	if err != nil {
 		e.ValidationError(err)
 		service.ServerResponse(w, e)
 		return
 	}
 
-	if exists(serie, number) != uuid.Nil {
-		e.DBExists()
+	org, err := h.Users.ReadOrganizationByJoinCode(newUsr.OrganizationCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			e.ValidationError(&service.FieldError{
+				Field:   "organizationCode",
+				Path:    "/organizationCode",
+				Rule:    validation.RuleExists,
+				Message: "unknown organization code",
+			})
+		} else {
+			e.DBError(err)
+		}
 		service.ServerResponse(w, e)
 		return
 	}
 
-	var extUser ExternalUser
-	err = extUser.GetExternalData(serie, number)
-	if err != nil {
-		e.ExternalAPIError(err)
+	if h.Users.PassportOwner(org.OrganizationId, serie, number) != uuid.Nil {
+		e.DBPassportExists()
 		service.ServerResponse(w, e)
 		return
 	}
 
-	err = extUser.ValidateRequiredFields()
-	if err != nil {
-		e.ValidationError(err)
+	if h.Users.LoginExists(newUsr.Login) {
+		e.DBLoginExists()
 		service.ServerResponse(w, e)
 		return
 	}
 
 	usr := FullUser{
-		PassportSerie:  serie,
-		PassportNumber: number,
-		Name:           extUser.Name,
-		Surname:        extUser.Surname,
-		Patronymic:     extUser.Patronymic,
-		Address:        extUser.Address,
-		UserId:         uuid.New(),
+		PassportSerie:    serie,
+		PassportNumber:   number,
+		UserId:           uuid.New(),
+		Login:            newUsr.Login,
+		Role:             RoleMember,
+		OrganizationId:   org.OrganizationId,
+		EnrichmentStatus: EnrichmentPending,
+	}
+
+	if h.Queue == nil {
+		person, err := h.Enricher.Enrich(r.Context(), enrichment.Request{
+			PassportSerie:  serie,
+			PassportNumber: number,
+			Submitted:      newUsr.person(),
+		})
+		if err != nil {
+			e.ExternalAPIError(err)
+			service.ServerResponse(w, e)
+			return
+		}
+
+		err = person.ValidateRequiredFields()
+		if err != nil {
+			e.ValidationError(err)
+			service.ServerResponse(w, e)
+			return
+		}
+
+		usr.Name, usr.Surname, usr.Patronymic, usr.Address = person.Name, person.Surname, person.Patronymic, person.Address
+		usr.EnrichmentStatus = EnrichmentComplete
+	} else {
+		submitted := newUsr.person()
+		usr.SubmittedPerson = &submitted
 	}
 
-	err = usr.Create()
+	err = usr.SetPassword(newUsr.Password)
 	if err != nil {
-		e.DBError(err)
+		e.Error500(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	err = h.Users.Create(&usr)
+	if err != nil {
+		e.FromError(err)
 		service.ServerResponse(w, e)
+		return
 	}
 
-	msg := "User created successfully"
+	code, msg := http.StatusCreated, "User created successfully"
+	if h.Queue != nil {
+		h.Queue.Enqueue(usr)
+		code, msg = http.StatusAccepted, "User created, personal data is being requested"
+	}
 
 	service.ServerResponse(w, service.OkResponse{
-		Code:    http.StatusOK,
+		Code:    code,
 		Message: msg,
 		Data:    usr.UserId,
 	})
@@ -102,21 +177,32 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 // ReadUserByIDHandler godoc
 //
 //	@Summary		Get user
-//	@Description	Get user by UUID
+//	@Description	Get user by UUID. ETag header holds the user version for If-Match on update and delete.
 //	@Tags			User
 //	@Produce		json
+//	@Security		BearerAuth
 //	@Param			uuid	path		string	true	"Provide user's uuid"
 //	@Success		200		{object}	FullUser
+//	@Header			200		{string}	ETag	"User version"
 //	@Failure		400		{object}	service.ErrorResponse
+//	@Failure		401		{object}	service.ErrorResponse
+//	@Failure		403		{object}	service.ErrorResponse
 //	@Failure		404		{object}	service.ErrorResponse
 //	@Failure		500		{object}	service.ErrorResponse
 //	@Router			/user/{uuid} [get]
-func ReadUserByIDHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) ReadUserByIDHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
 	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
 
+	actor, err := CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
 	userId, err := uuid.Parse(r.PathValue("uuid"))
 	if err != nil {
 		e.UuidParseError(err)
@@ -124,18 +210,20 @@ func ReadUserByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	usr := FullUser{UserId: userId}
-	err = usr.ReadOne()
+	usr, err := h.Users.ReadOne(actor.OrganizationId, userId)
 	if err != nil {
-		if err.Error() == "record not found" {
-			e.Error404()
-		} else {
-			e.DBError(err)
-		}
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	if !actor.CanReadUser(&usr) {
+		e.Error403(service.ErrForbidden)
 		service.ServerResponse(w, e)
 		return
 	}
 
+	w.Header().Set("ETag", service.ETag(usr.Version))
 	service.ServerResponse(w, usr)
 	log.Info("User read successfully")
 }
@@ -143,36 +231,56 @@ func ReadUserByIDHandler(w http.ResponseWriter, r *http.Request) {
 // ReadManyHandler godoc
 //
 //	@Summary		Get all users
-//	@Description	Get all users with filters and pagination
+//	@Description	Get all users with filters and pagination. Managers get only their team, members only themselves.
 //	@Tags			User
 //	@Produce		json
+//	@Security		BearerAuth
 //	@Param			passportSerie	query		int		false	"Passport serie"
 //	@Param			passportNumber	query		int		false	"Passport number"
 //	@Param			name			query		string	false	"Name"
 //	@Param			surname			query		string	false	"Surname"
 //	@Param			patronymic		query		string	false	"Patronymic"
 //	@Param			address			query		string	false	"Address"
+//	@Param			login			query		string	false	"Login"
+//	@Param			role			query		string	false	"Role"
+//	@Param			managerId		query		string	false	"Manager UUID"
 //	@Param			userId			query		string	false	"User UUID"
 //	@Param			page			query		int		false	"Page number"
 //	@Param			perPage			query		int		false	"Records per page"
 //
 //	@Success		200				{array}		FullUser
 //	@Failure		400				{object}	service.ErrorResponse
+//	@Failure		401				{object}	service.ErrorResponse
+//	@Failure		403				{object}	service.ErrorResponse
 //	@Failure		404				{object}	service.ErrorResponse
 //	@Failure		500				{object}	service.ErrorResponse
 //	@Router			/user [get]
-func ReadManyHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) ReadManyHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
 	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
 
+	actor, err := CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
 	queryParams := r.URL.Query()
 	filters := filtersMap(queryParams)
 	params := paginationParams(queryParams)
 
-	var usr FullUser
-	users, err := usr.ReadMany(filters, params)
+	switch {
+	case actor.IsAdmin():
+	case actor.Role == RoleManager:
+		filters["manager_id"] = actor.UserId
+	default:
+		filters["user_id"] = actor.UserId
+	}
+
+	users, err := h.Users.ReadMany(actor.OrganizationId, filters, params)
 	if err != nil {
 		e.DBError(err)
 		service.ServerResponse(w, e)
@@ -192,26 +300,134 @@ func ReadManyHandler(w http.ResponseWriter, r *http.Request) {
 	}).Info("Users read successfully")
 }
 
+// RefreshUserHandler godoc
+//
+//	@Summary		Refresh user data
+//	@Description	Request name, surname, patronymic and address of the user from the enrichment provider again, bypassing the cache, and return the field-level diff.
+//	@Description	Changes are applied unless dryRun is set. Users may refresh their own profile, only admins may refresh other users.
+//	@Description	With If-Match changes are applied only if the user wasn't modified since its ETag was read.
+//	@Tags			User
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			uuid		path		string	true	"Provide user's uuid"
+//	@Param			dryRun		query		bool	false	"Only return the diff"
+//	@Param			If-Match	header		string	false	"ETag of the user"
+//	@Success		200			{object}	service.OkResponse{data=RefreshResult}
+//	@Failure		400			{object}	service.ErrorResponse
+//	@Failure		401			{object}	service.ErrorResponse
+//	@Failure		403			{object}	service.ErrorResponse
+//	@Failure		404			{object}	service.ErrorResponse
+//	@Failure		412			{object}	service.ErrorResponse
+//	@Failure		500			{object}	service.ErrorResponse
+//	@Failure		502			{object}	service.ErrorResponse
+//	@Failure		503			{object}	service.ErrorResponse
+//	@Router			/user/{uuid}/refresh [post]
+func (h *Handler) RefreshUserHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	actor, err := CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	userId, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		e.UuidParseError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	if !actor.CanManageUsers() && actor.UserId != userId {
+		e.Error403(service.ErrForbidden)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	dryRun, err := dryRunParam(r.URL.Query())
+	if err != nil {
+		e.ValidationError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	usr, err := h.Users.ReadOne(actor.OrganizationId, userId)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	version, err := service.IfMatch(r, usr.Version)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	result, err := refreshUser(r.Context(), h.Users, h.Enricher, &usr, version, dryRun)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	msg := "User data is up to date"
+	switch {
+	case result.Applied:
+		msg = "User data refreshed"
+	case len(result.Changes) > 0:
+		msg = "User data differs, changes not applied"
+	}
+
+	service.ServerResponse(w, service.OkResponse{
+		Code:    http.StatusOK,
+		Message: msg,
+		Data:    result,
+	})
+	log.WithFields(log.Fields{"changes": len(result.Changes), "dry_run": dryRun}).Info(msg)
+}
+
 // UpdateUserHandler godoc
 //
 //	@Summary		Update user
-//	@Description	Update user by UUID
+//	@Description	Update user by UUID. Users may update their own profile, only admins may update other users, roles and managers.
+//	@Description	The last admin of the organization can't be given another role.
+//	@Description	With If-Match the update is applied only if the user wasn't modified since its ETag was read.
 //	@Tags			User
 //	@Accept			json
 //	@Produce		json
-//	@Param			uuid	path		string	true		"Provide user's uuid"
-//	@Param			User	data		body	UpdateUser	true	"Passport serie and number are required. Partial update possible, empty fields will be ignored."
-//	@Success		200		{object}	service.OkResponse
-//	@Failure		400		{object}	service.ErrorResponse
-//	@Failure		404		{object}	service.ErrorResponse
-//	@Failure		500		{object}	service.ErrorResponse
+//	@Security		BearerAuth
+//	@Param			uuid		path		string	true		"Provide user's uuid"
+//	@Param			If-Match	header		string	false		"ETag of the user"
+//	@Param			User		data		body	UpdateUser	true	"Passport serie and number are required. Partial update possible, empty fields will be ignored."
+//	@Success		200			{object}	service.OkResponse
+//	@Failure		400			{object}	service.ErrorResponse
+//	@Failure		401			{object}	service.ErrorResponse
+//	@Failure		403			{object}	service.ErrorResponse
+//	@Failure		404			{object}	service.ErrorResponse
+//	@Failure		409			{object}	service.ErrorResponse
+//	@Failure		412			{object}	service.ErrorResponse
+//	@Failure		413			{object}	service.ErrorResponse
+//	@Failure		500			{object}	service.ErrorResponse
 //	@Router			/user/{uuid} [put]
-func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
 	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
 
+	actor, err := CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
 	userId, err := uuid.Parse(r.PathValue("uuid"))
 	if err != nil {
 		e.UuidParseError(err)
@@ -219,7 +435,7 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	usr := UpdateUser{UserId: userId}
+	usr := UpdateUser{UserId: userId, OrganizationId: actor.OrganizationId}
 
 	data, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -236,33 +452,43 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if usr.PassportSerie != 0 || usr.PassportNumber != 0 {
-		log.Error("KEK")
-		pn := fmt.Sprintf("%s %s", strconv.Itoa(usr.PassportSerie), strconv.Itoa(usr.PassportNumber))
-
-		_, _, err = validatePassportNumber(pn)
-		if err != nil {
+	err = usr.validate(&actor, h.Users)
+	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			e.Error403(err)
+		} else {
 			e.ValidationError(err)
-			service.ServerResponse(w, e)
-			return
 		}
+		service.ServerResponse(w, e)
+		return
+	}
 
-		id := exists(usr.PassportSerie, usr.PassportNumber)
+	if usr.PassportSerie != 0 || usr.PassportNumber != 0 {
+		id := h.Users.PassportOwner(usr.OrganizationId, usr.PassportSerie, usr.PassportNumber)
 		if id != usr.UserId && id != uuid.Nil {
-			log.Error(usr.UserId)
 			e.DBPassportExists()
 			service.ServerResponse(w, e)
 			return
 		}
 	}
 
-	err = usr.Update()
+	current, err := h.Users.ReadOne(usr.OrganizationId, usr.UserId)
 	if err != nil {
-		if err.Error() == "404" {
-			e.Error404()
-		} else {
-			e.DBError(err)
-		}
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	usr.Version, err = service.IfMatch(r, current.Version)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	err = updateUser(h.Store, &usr)
+	if err != nil {
+		e.FromError(err)
 		service.ServerResponse(w, e)
 		return
 	}
@@ -270,7 +496,7 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 	msg := "User updated successfully"
 
 	service.ServerResponse(w, service.OkResponse{
-		Code:    204,
+		Code:    http.StatusOK,
 		Message: msg,
 		Data:    "",
 	})
@@ -280,21 +506,45 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 // DeleteUserHandler godoc
 //
 //	@Summary		Delete user
-//	@Description	Delete user by UUID
+//	@Description	Delete user by UUID. Admins only, the last admin of the organization can't be deleted.
+//	@Description	Policy for user's tasks: "block" (default) refuses to delete a user with tasks, "cascade" deletes the tasks too,
+//	@Description	"reassign" hands them over to the user given in reassignTo. Everything is done in one transaction.
+//	@Description	With If-Match the user is deleted only if it wasn't modified since its ETag was read.
 //	@Tags			User
 //	@Produce		json
-//	@Param			uuid	path		string	true	"Provide user's uuid"
-//	@Success		200		{object}	service.OkResponse
-//	@Failure		400		{object}	service.ErrorResponse
-//	@Failure		404		{object}	service.ErrorResponse
-//	@Failure		500		{object}	service.ErrorResponse
+//	@Security		BearerAuth
+//	@Param			uuid		path		string	true	"Provide user's uuid"
+//	@Param			If-Match	header		string	false	"ETag of the user"
+//	@Param			tasks		query		string	false	"Tasks policy"	Enums(block, cascade, reassign)
+//	@Param			reassignTo	query		string	false	"UUID of the new tasks owner, required for reassign"
+//	@Success		204			"No Content"
+//	@Failure		400			{object}	service.ErrorResponse
+//	@Failure		401			{object}	service.ErrorResponse
+//	@Failure		403			{object}	service.ErrorResponse
+//	@Failure		404			{object}	service.ErrorResponse
+//	@Failure		409			{object}	service.ErrorResponse
+//	@Failure		412			{object}	service.ErrorResponse
+//	@Failure		500			{object}	service.ErrorResponse
 //	@Router			/user/{uuid} [delete]
-func DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var e service.ErrorResponse
 
 	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
 
+	actor, err := CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	if !actor.CanManageUsers() {
+		e.Error403(service.ErrForbidden)
+		service.ServerResponse(w, e)
+		return
+	}
+
 	userId, err := uuid.Parse(r.PathValue("uuid"))
 	if err != nil {
 		e.UuidParseError(err)
@@ -302,21 +552,217 @@ func DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	usr := FullUser{UserId: userId}
+	policy, err := deletePolicy(r.URL.Query())
+	if err != nil {
+		e.ValidationError(err)
+		service.ServerResponse(w, e)
+		return
+	}
 
-	err = usr.Delete()
+	usr, err := h.Users.ReadOne(actor.OrganizationId, userId)
 	if err != nil {
-		e.Error404()
+		e.FromError(err)
 		service.ServerResponse(w, e)
 		return
 	}
 
+	version, err := service.IfMatch(r, usr.Version)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	err = deleteUser(h.Store, actor.OrganizationId, userId, version, policy)
+	if err != nil {
+		e.FromError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	// keys of deleted users are rejected by the auth middleware anyway
+	err = h.Keys.RevokeAll(userId)
+	if err != nil {
+		log.WithField("user_id", userId).Warn("API keys of the deleted user not revoked: ", err)
+	}
+
 	msg := "User deleted successfully"
 
 	service.ServerResponse(w, service.OkResponse{
-		Code:    204,
+		Code:    http.StatusNoContent,
 		Message: msg,
 		Data:    "",
 	})
+	log.WithField("tasks", policy.Tasks).Info(msg)
+}
+
+// LoginHandler godoc
+//
+//	@Summary		Login
+//	@Description	Exchange login and password for access and refresh tokens
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			Credentials	body		Credentials	true	"User's login and password"
+//	@Success		200			{object}	service.OkResponse{data=auth.TokenPair}
+//	@Failure		400			{object}	service.ErrorResponse
+//	@Failure		401			{object}	service.ErrorResponse
+//	@Failure		413			{object}	service.ErrorResponse
+//	@Failure		500			{object}	service.ErrorResponse
+//	@Router			/auth/login [post]
+func (h *Handler) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		e.ReadBodyError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+	defer r.Body.Close()
+
+	var creds Credentials
+	err = service.DeserializeJSON(data, &creds)
+	if err != nil {
+		e.DeserializeError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	usr, err := h.Users.ReadByLogin(creds.Login)
+	if err != nil || !usr.CheckPassword(creds.Password) {
+		e.Error401(errors.New("invalid login or password"))
+		service.ServerResponse(w, e)
+		return
+	}
+
+	tokens, err := auth.IssueTokenPair(usr.UserId)
+	if err != nil {
+		e.Error500(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	msg := "Login successful"
+
+	service.ServerResponse(w, service.OkResponse{
+		Code:    http.StatusOK,
+		Message: msg,
+		Data:    tokens,
+	})
+	log.WithField("login", usr.Login).Info(msg)
+}
+
+// RefreshHandler godoc
+//
+//	@Summary		Refresh tokens
+//	@Description	Exchange a refresh token for a new pair of access and refresh tokens
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			Refresh	token		body	RefreshRequest	true	"Refresh token issued by login"
+//	@Success		200		{object}	service.OkResponse{data=auth.TokenPair}
+//	@Failure		400		{object}	service.ErrorResponse
+//	@Failure		401		{object}	service.ErrorResponse
+//	@Failure		413		{object}	service.ErrorResponse
+//	@Failure		500		{object}	service.ErrorResponse
+//	@Router			/auth/refresh [post]
+func (h *Handler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		e.ReadBodyError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+	defer r.Body.Close()
+
+	var req RefreshRequest
+	err = service.DeserializeJSON(data, &req)
+	if err != nil {
+		e.DeserializeError(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	userId, err := auth.ParseRefreshToken(req.RefreshToken)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	usr, err := h.Users.ReadById(userId)
+	if err != nil {
+		e.Error401(errors.New("user not found"))
+		service.ServerResponse(w, e)
+		return
+	}
+
+	tokens, err := auth.IssueTokenPair(usr.UserId)
+	if err != nil {
+		e.Error500(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	msg := "Tokens refreshed successfully"
+
+	service.ServerResponse(w, service.OkResponse{
+		Code:    http.StatusOK,
+		Message: msg,
+		Data:    tokens,
+	})
 	log.Info(msg)
 }
+
+// ReadOrganizationHandler godoc
+//
+//	@Summary		Get organization
+//	@Description	Get organization of the authenticated user. Join code is shown to admins only.
+//	@Tags			Organization
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Organization
+//	@Failure		401	{object}	service.ErrorResponse
+//	@Failure		404	{object}	service.ErrorResponse
+//	@Failure		500	{object}	service.ErrorResponse
+//	@Router			/organization [get]
+func (h *Handler) ReadOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var e service.ErrorResponse
+
+	log.Info(r.Method, " ", r.URL.Path, " ", r.RemoteAddr, " ", r.UserAgent())
+
+	actor, err := CurrentUser(r, h.Users)
+	if err != nil {
+		e.Error401(err)
+		service.ServerResponse(w, e)
+		return
+	}
+
+	org, err := h.Users.ReadOrganization(actor.OrganizationId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			e.Error404()
+		} else {
+			e.DBError(err)
+		}
+		service.ServerResponse(w, e)
+		return
+	}
+
+	if !actor.IsAdmin() {
+		org.JoinCode = ""
+	}
+
+	service.ServerResponse(w, org)
+	log.Info("Organization read successfully")
+}
@@ -0,0 +1,240 @@
+package user_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time_tracker/api/apitest"
+	"time_tracker/api/auth"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/service"
+	"time_tracker/api/task"
+	"time_tracker/api/user"
+)
+
+// testServer serves the user and task handlers on memory repositories.
+type testServer struct {
+	t       *testing.T
+	handler http.Handler
+	users   *user.MemoryRepository
+	tasks   *task.MemoryRepository
+	keys    *auth.MemoryRepository
+	org     user.Organization
+	admin   user.FullUser
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	apitest.Setup()
+
+	users := user.NewMemoryRepository()
+	tasks := task.NewMemoryRepository()
+	store := task.NewMemoryStore(users, tasks)
+	enricher := enrichment.NoopProvider{}
+	org, admin := apitest.CreateOrganization(t, store, enricher)
+
+	keys := auth.NewMemoryRepository()
+	router := http.NewServeMux()
+	user.NewHandler(users, store, keys, enricher, nil).AddRoutes(router)
+	task.NewHandler(tasks, users).AddRoutes(router)
+	handler := auth.NewHandler(keys, users).Middleware(router)
+
+	return &testServer{t: t, handler: handler, users: users, tasks: tasks, keys: keys, org: org, admin: admin}
+}
+
+// do serves the request made by the user, uuid.Nil makes it anonymous, and
+// returns the status and the problem code of errors.
+func (s *testServer) do(userId uuid.UUID, method, path, body string) (int, string) {
+	s.t.Helper()
+
+	r := httptest.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+	if userId != uuid.Nil {
+		tokens, err := auth.IssueTokenPair(userId)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		r.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	}
+
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, r)
+
+	var problem service.ErrorResponse
+	if w.Code >= 400 {
+		err := json.Unmarshal(w.Body.Bytes(), &problem)
+		if err != nil {
+			s.t.Fatalf("%s %s: %v: %s", method, path, err, w.Body)
+		}
+	}
+	return w.Code, problem.Code
+}
+
+func (s *testServer) createMember(login, passport string) user.FullUser {
+	s.t.Helper()
+
+	status, code := s.do(uuid.Nil, "POST", "/user", body(s.t, apitest.NewMember(login, passport, s.org.JoinCode)))
+	if status != http.StatusCreated {
+		s.t.Fatalf("member not created: %d %s", status, code)
+	}
+
+	usr, err := s.users.ReadByLogin(login)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return usr
+}
+
+func (s *testServer) createTask(owner user.FullUser) task.FullTask {
+	s.t.Helper()
+
+	tsk := task.FullTask{TaskId: uuid.New(), OwnerId: owner.UserId, OrganizationId: owner.OrganizationId, Title: "Report"}
+	err := s.tasks.Create(&tsk)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return tsk
+}
+
+// body marshals the request body to JSON.
+func body(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func expect(t *testing.T, status int, code string, wantStatus int, wantCode string) {
+	t.Helper()
+
+	if status != wantStatus || code != wantCode {
+		t.Fatalf("expected %d %q, got %d %q", wantStatus, wantCode, status, code)
+	}
+}
+
+func TestCreateUserHandler(t *testing.T) {
+	s := newTestServer(t)
+
+	member := s.createMember("member", "2000 200000")
+	if member.Role != user.RoleMember || member.OrganizationId != s.org.OrganizationId {
+		t.Fatalf("expected member of the organization, got %s of %s", member.Role, member.OrganizationId)
+	}
+
+	status, code := s.do(uuid.Nil, "POST", "/user", body(t, apitest.NewMember("other", "2000 200000", s.org.JoinCode)))
+	expect(t, status, code, http.StatusConflict, "user.passport_exists")
+
+	status, code = s.do(uuid.Nil, "POST", "/user", body(t, apitest.NewMember("member", "3000 300000", s.org.JoinCode)))
+	expect(t, status, code, http.StatusConflict, "user.login_exists")
+
+	status, code = s.do(uuid.Nil, "POST", "/user", body(t, apitest.NewMember("other", "3000 300000", "unknown")))
+	expect(t, status, code, http.StatusBadRequest, "validation.failed")
+}
+
+func TestDeleteUserHandlerTasksPolicy(t *testing.T) {
+	s := newTestServer(t)
+	member := s.createMember("member", "2000 200000")
+	tsk := s.createTask(member)
+	path := fmt.Sprintf("/user/%s", member.UserId)
+
+	status, code := s.do(member.UserId, "DELETE", path, "")
+	expect(t, status, code, http.StatusForbidden, "auth.forbidden")
+
+	status, code = s.do(s.admin.UserId, "DELETE", path, "")
+	expect(t, status, code, http.StatusConflict, "user.has_tasks")
+
+	status, code = s.do(s.admin.UserId, "DELETE", path+"?tasks=reassign&reassignTo="+uuid.NewString(), "")
+	expect(t, status, code, http.StatusBadRequest, "validation.failed")
+
+	_, err := s.users.ReadOne(s.org.OrganizationId, member.UserId)
+	if err != nil {
+		t.Fatal("user deleted by a failed request: ", err)
+	}
+
+	status, code = s.do(s.admin.UserId, "DELETE", path+"?tasks=reassign&reassignTo="+s.admin.UserId.String(), "")
+	expect(t, status, code, http.StatusNoContent, "")
+
+	reassigned, err := s.tasks.ReadOne(s.org.OrganizationId, tsk.TaskId)
+	if err != nil || reassigned.OwnerId != s.admin.UserId {
+		t.Fatalf("expected task of the admin, got %s, %v", reassigned.OwnerId, err)
+	}
+
+	status, code = s.do(s.admin.UserId, "GET", path, "")
+	expect(t, status, code, http.StatusNotFound, "not_found")
+}
+
+func TestDeleteUserHandlerCascade(t *testing.T) {
+	s := newTestServer(t)
+	member := s.createMember("member", "2000 200000")
+	s.createTask(member)
+	s.createTask(member)
+	err := s.keys.Create(&auth.APIKey{KeyId: uuid.New(), UserId: member.UserId, Name: "CI", KeyHash: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, code := s.do(s.admin.UserId, "DELETE", fmt.Sprintf("/user/%s?tasks=cascade", member.UserId), "")
+	expect(t, status, code, http.StatusNoContent, "")
+
+	count, err := s.tasks.CountByOwner(member.UserId)
+	if err != nil || count != 0 {
+		t.Fatalf("expected tasks deleted, got %d, %v", count, err)
+	}
+
+	status, code = s.do(member.UserId, "GET", fmt.Sprintf("/tasks/%s", member.UserId), "")
+	expect(t, status, code, http.StatusUnauthorized, "auth.unauthorized")
+
+	keys, err := s.keys.ReadMany(member.UserId)
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("expected API keys of the deleted user revoked, got %d, %v", len(keys), err)
+	}
+}
+
+func TestLastAdmin(t *testing.T) {
+	s := newTestServer(t)
+	admin := fmt.Sprintf("/user/%s", s.admin.UserId)
+
+	status, code := s.do(s.admin.UserId, "DELETE", admin, "")
+	expect(t, status, code, http.StatusConflict, "user.last_admin")
+
+	status, code = s.do(s.admin.UserId, "PUT", admin, `{"role":"member"}`)
+	expect(t, status, code, http.StatusConflict, "user.last_admin")
+
+	member := s.createMember("member", "2000 200000")
+	status, code = s.do(s.admin.UserId, "PUT", fmt.Sprintf("/user/%s", member.UserId), `{"role":"admin"}`)
+	expect(t, status, code, http.StatusOK, "")
+
+	status, code = s.do(member.UserId, "PUT", admin, `{"role":"member"}`)
+	expect(t, status, code, http.StatusOK, "")
+
+	status, code = s.do(member.UserId, "DELETE", fmt.Sprintf("/user/%s", member.UserId), "")
+	expect(t, status, code, http.StatusConflict, "user.last_admin")
+}
+
+func TestUserWithoutOrganization(t *testing.T) {
+	s := newTestServer(t)
+	member := s.createMember("member", "2000 200000")
+
+	// a legacy user not adopted into an organization yet
+	legacy := user.FullUser{UserId: uuid.New(), Login: "legacy", Role: user.RoleAdmin, PassportSerie: 3000, PassportNumber: 300000}
+	err := s.users.Create(&legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, code := s.do(legacy.UserId, "GET", fmt.Sprintf("/user/%s", member.UserId), "")
+	expect(t, status, code, http.StatusNotFound, "not_found")
+
+	status, code = s.do(legacy.UserId, "DELETE", fmt.Sprintf("/user/%s", member.UserId), "")
+	expect(t, status, code, http.StatusNotFound, "not_found")
+
+	_, err = s.users.ReadOne(uuid.Nil, legacy.UserId)
+	if err == nil {
+		t.Fatal("expected no user read without an organization")
+	}
+}
@@ -1,14 +1,21 @@
 package user
 
 import (
-	"errors"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"github.com/google/uuid"
 	"net/url"
 	"strconv"
 	"strings"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/service"
+	"time_tracker/api/validation"
 )
 
-func validatePassportNumber(p string) (int, int, error) {
+// validatePassportNumber parses passport in format '1234 567890', problems
+// with serie and number are reported together.
+func validatePassportNumber(v *validation.Validator, field, p string) (int, int) {
 	raw := strings.Split(p, " ")
 	var data []string
 
@@ -20,27 +27,216 @@ func validatePassportNumber(p string) (int, int, error) {
 	}
 
 	if len(data) != 2 {
-		return 0, 0, errors.New("invalid passport number, use format: '1234 56780'")
+		v.Add(field, validation.RuleFormat, "invalid passport number, use format: '1234 56780'")
+		return 0, 0
 	}
 
-	if len(data[0]) != 4 {
-		return 0, 0, errors.New("passport serie must be 4 characters long")
+	v.Check(len(data[0]) == 4, field, validation.RuleLength, "passport serie must be 4 characters long")
+	v.Check(len(data[1]) == 6, field, validation.RuleLength, "passport number must be 6 characters long")
+
+	serie, err := strconv.Atoi(data[0])
+	v.Check(err == nil, field, validation.RuleFormat, "passport serie must contain only numbers")
+
+	number, err := strconv.Atoi(data[1])
+	v.Check(err == nil, field, validation.RuleFormat, "passport number must contain only numbers")
+
+	return serie, number
+}
+
+func validateCredentials(v *validation.Validator, login, password string) {
+	if v.Required("login", login) {
+		v.Check(!strings.ContainsAny(login, " \t\n"), "login", validation.RuleFormat,
+			"login can't contain whitespaces")
+	}
+	v.MinLength("password", password, 8)
+}
+
+func (n *NewUser) validate() (int, int, error) {
+	v := validation.New()
+	serie, number := validatePassportNumber(v, "passportNumber", n.PassportNumber)
+	validateCredentials(v, n.Login, n.Password)
+	v.Required("organizationCode", n.OrganizationCode)
+	return serie, number, v.Err()
+}
+
+// person returns the personal data sent with the request.
+func (n *NewUser) person() enrichment.Person {
+	return enrichment.Person{
+		Name:       n.Name,
+		Patronymic: n.Patronymic,
+		Surname:    n.Surname,
+		Address:    n.Address,
 	}
-	if len(data[1]) != 6 {
-		return 0, 0, errors.New("passport number must be 6 characters long")
+}
+
+// person returns the stored personal data of the user.
+func (f *FullUser) person() enrichment.Person {
+	return enrichment.Person{
+		Name:       f.Name,
+		Patronymic: f.Patronymic,
+		Surname:    f.Surname,
+		Address:    f.Address,
 	}
+}
 
-	serie, err := strconv.Atoi(data[0])
+// validate checks that actor may apply the update and collects field errors.
+// Only admins may update other users, roles and managers.
+func (u *UpdateUser) validate(actor *FullUser, users UserRepository) error {
+	if !actor.CanManageUsers() && (actor.UserId != u.UserId || u.Role != "" || u.ManagerId != uuid.Nil) {
+		return service.ErrForbidden
+	}
+
+	v := validation.New()
+
+	if u.PassportSerie != 0 || u.PassportNumber != 0 {
+		v.Check(u.PassportSerie >= 1000 && u.PassportSerie <= 9999, "passportSerie", validation.RuleLength,
+			"passport serie must be 4 characters long")
+		v.Check(u.PassportNumber >= 100000 && u.PassportNumber <= 999999, "passportNumber", validation.RuleLength,
+			"passport number must be 6 characters long")
+	}
+
+	if u.Role != "" {
+		v.OneOf("role", u.Role, RoleAdmin, RoleManager, RoleMember)
+	}
+
+	if u.ManagerId != uuid.Nil {
+		_, err := users.ReadOne(u.OrganizationId, u.ManagerId)
+		v.Check(err == nil, "managerId", validation.RuleExists, "manager not found")
+	}
+
+	return v.Err()
+}
+
+// deletePolicy reads the policy from query parameters tasks and reassignTo,
+// tasks defaults to block.
+func deletePolicy(queryParams url.Values) (DeletePolicy, error) {
+	policy := DeletePolicy{Tasks: queryParams.Get("tasks")}
+	if policy.Tasks == "" {
+		policy.Tasks = TasksBlock
+	}
+
+	v := validation.New()
+	v.OneOf("tasks", policy.Tasks, TasksBlock, TasksCascade, TasksReassign)
+
+	if policy.Tasks == TasksReassign && v.Required("reassignTo", queryParams.Get("reassignTo")) {
+		id, err := uuid.Parse(queryParams.Get("reassignTo"))
+		v.Check(err == nil, "reassignTo", validation.RuleFormat, "reassignTo must be a valid UUID")
+		policy.ReassignTo = id
+	}
+
+	return policy, v.Err()
+}
+
+// dryRunParam parses the optional dryRun query parameter.
+func dryRunParam(queryParams url.Values) (bool, error) {
+	value := queryParams.Get("dryRun")
+	if value == "" {
+		return false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(value)
+	v := validation.New()
+	v.Check(err == nil, "dryRun", validation.RuleFormat, "dryRun must be true or false")
+	return dryRun, v.Err()
+}
+
+// deleteUser deletes the user and applies policy to their tasks in one
+// transaction, a non-zero version must match the stored user version.
+// The last admin of the organization can't be deleted.
+func deleteUser(store Store, organizationId, userId uuid.UUID, version int64, policy DeletePolicy) error {
+	return store.Transaction(func(users UserRepository, tasks OwnedTasks) error {
+		err := keepAdmin(users, organizationId, userId)
+		if err != nil {
+			return err
+		}
+
+		count, err := tasks.CountByOwner(userId)
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			switch policy.Tasks {
+			case TasksCascade:
+				err = tasks.DeleteByOwner(userId)
+			case TasksReassign:
+				err = reassignTasks(users, tasks, organizationId, userId, policy.ReassignTo)
+			default:
+				err = fmt.Errorf("%w: %d task(s), delete them or choose tasks=cascade or tasks=reassign",
+					service.ErrUserHasTasks, count)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return users.Delete(organizationId, userId, version)
+	})
+}
+
+// updateUser applies upd in one transaction, the last admin of the
+// organization can't be given another role.
+func updateUser(store Store, upd *UpdateUser) error {
+	return store.Transaction(func(users UserRepository, _ OwnedTasks) error {
+		if upd.Role != "" && upd.Role != RoleAdmin {
+			err := keepAdmin(users, upd.OrganizationId, upd.UserId)
+			if err != nil {
+				return err
+			}
+		}
+		return users.Update(upd)
+	})
+}
+
+// keepAdmin returns ErrLastAdmin if the user is the only admin of the
+// organization. The organization stays locked until the transaction ends, so
+// that concurrent requests can't remove two last admins at once.
+func keepAdmin(users UserRepository, organizationId, userId uuid.UUID) error {
+	err := users.LockOrganization(organizationId)
 	if err != nil {
-		return 0, 0, errors.New("passport serie must contain only numbers")
+		return err
 	}
 
-	number, err := strconv.Atoi(data[1])
+	usr, err := users.ReadOne(organizationId, userId)
+	if err != nil {
+		return err
+	}
+	if !usr.IsAdmin() {
+		return nil
+	}
+
+	count, err := users.CountAdmins(organizationId)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return service.ErrLastAdmin
+	}
+	return nil
+}
+
+func reassignTasks(users UserRepository, tasks OwnedTasks, organizationId, userId, newOwnerId uuid.UUID) error {
+	v := validation.New()
+	v.Check(newOwnerId != userId, "reassignTo", validation.RuleOneOf, "tasks can't be reassigned to the deleted user")
+	if v.Valid() {
+		_, err := users.ReadOne(organizationId, newOwnerId)
+		v.Check(err == nil, "reassignTo", validation.RuleExists, "user to reassign tasks to not found")
+	}
+
+	err := v.Err()
 	if err != nil {
-		return 0, 0, errors.New("passport number must contain only numbers")
+		return err
 	}
+	return tasks.ReassignOwner(userId, newOwnerId)
+}
 
-	return serie, number, nil
+func generateJoinCode() (string, error) {
+	buf := make([]byte, 8)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
 
 func filtersMap(queryParams url.Values) map[string]interface{} {
@@ -78,6 +274,22 @@ func filtersMap(queryParams url.Values) map[string]interface{} {
 		filters["address"] = address
 	}
 
+	login := queryParams.Get("login")
+	if login != "" {
+		filters["login"] = login
+	}
+
+	role := queryParams.Get("role")
+	if role != "" {
+		filters["role"] = role
+	}
+
+	managerId := queryParams.Get("managerId")
+	if managerId != "" {
+		mid, _ := uuid.Parse(managerId)
+		filters["manager_id"] = mid
+	}
+
 	userId := queryParams.Get("userId")
 	if userId != "" {
 		uid, _ := uuid.Parse(userId)
@@ -5,13 +5,8 @@ import (
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
-
-func Init(d *gorm.DB) {
-	DB = d //passing DB global var
-	err := DB.AutoMigrate(&FullUser{})
-	if err != nil {
-		log.Fatal(err)
-	}
+// Init returns the repository backed by d, tables are created by migrations.
+func Init(d *gorm.DB) *GormRepository {
 	log.Info("User model init success")
+	return NewGormRepository(d)
 }
@@ -0,0 +1,495 @@
+package user
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"sort"
+	"sync"
+	"time"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/service"
+)
+
+// MemoryRepository keeps users in memory, it mimics soft deletes, unique
+// indexes and error values of GormRepository and is meant for tests.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	nextId uint
+	users  []FullUser
+	orgs   []Organization
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{}
+}
+
+func (m *MemoryRepository) Create(usr *FullUser) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, other := range m.users {
+		if other.DeletedAt.Valid {
+			continue
+		}
+		if other.Login == usr.Login {
+			return service.ErrLoginExists
+		}
+		if other.OrganizationId == usr.OrganizationId &&
+			other.PassportSerie == usr.PassportSerie && other.PassportNumber == usr.PassportNumber {
+			return service.ErrPassportExists
+		}
+	}
+
+	m.nextId++
+	now := time.Now()
+	usr.ID, usr.CreatedAt, usr.UpdatedAt = m.nextId, now, now
+	if usr.Role == "" {
+		usr.Role = RoleMember
+	}
+	if usr.Version == 0 {
+		usr.Version = 1
+	}
+	if usr.EnrichmentStatus == "" {
+		usr.EnrichmentStatus = EnrichmentComplete
+	}
+	m.users = append(m.users, *usr)
+	return nil
+}
+
+func (m *MemoryRepository) ReadOne(organizationId, userId uuid.UUID) (FullUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.find(organizationId, userId)
+	if i < 0 {
+		return FullUser{}, gorm.ErrRecordNotFound
+	}
+	return m.users[i], nil
+}
+
+func (m *MemoryRepository) ReadById(userId uuid.UUID) (FullUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.findById(userId)
+	if i < 0 {
+		return FullUser{}, gorm.ErrRecordNotFound
+	}
+	return m.users[i], nil
+}
+
+func (m *MemoryRepository) ReadByLogin(login string) (FullUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, usr := range m.users {
+		if !usr.DeletedAt.Valid && usr.Login == login {
+			return usr, nil
+		}
+	}
+	return FullUser{}, gorm.ErrRecordNotFound
+}
+
+func (m *MemoryRepository) ReadMany(organizationId uuid.UUID, filters map[string]interface{}, params map[string]int) ([]FullUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matched []FullUser
+	for _, usr := range m.users {
+		if usr.DeletedAt.Valid || usr.OrganizationId != organizationId || !matchFilters(&usr, filters) {
+			continue
+		}
+		matched = append(matched, usr)
+	}
+
+	offset := (params["page"] - 1) * params["per_page"]
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(matched) {
+		return nil, nil
+	}
+	matched = matched[offset:]
+	if params["per_page"] > 0 && len(matched) > params["per_page"] {
+		matched = matched[:params["per_page"]]
+	}
+	return matched, nil
+}
+
+func (m *MemoryRepository) Update(upd *UpdateUser) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.findVersion(upd.OrganizationId, upd.UserId, upd.Version)
+	if err != nil {
+		return err
+	}
+
+	usr := &m.users[i]
+	if upd.PassportSerie != 0 || upd.PassportNumber != 0 {
+		for j, other := range m.users {
+			if j != i && !other.DeletedAt.Valid && other.OrganizationId == usr.OrganizationId &&
+				other.PassportSerie == upd.PassportSerie && other.PassportNumber == upd.PassportNumber {
+				return service.ErrPassportExists
+			}
+		}
+	}
+
+	// zero values are skipped the same way gorm Updates does with structs
+	if upd.PassportSerie != 0 {
+		usr.PassportSerie = upd.PassportSerie
+	}
+	if upd.PassportNumber != 0 {
+		usr.PassportNumber = upd.PassportNumber
+	}
+	if upd.Name != "" {
+		usr.Name = upd.Name
+	}
+	if upd.Surname != "" {
+		usr.Surname = upd.Surname
+	}
+	if upd.Patronymic != "" {
+		usr.Patronymic = upd.Patronymic
+	}
+	if upd.Address != "" {
+		usr.Address = upd.Address
+	}
+	if upd.Role != "" {
+		usr.Role = upd.Role
+	}
+	if upd.ManagerId != uuid.Nil {
+		usr.ManagerId = upd.ManagerId
+	}
+	usr.Version++
+	usr.UpdatedAt = time.Now()
+	return nil
+}
+
+func (m *MemoryRepository) Delete(organizationId, userId uuid.UUID, version int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.findVersion(organizationId, userId, version)
+	if err != nil {
+		return err
+	}
+	m.users[i].Version++
+	m.users[i].DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (m *MemoryRepository) SetPasswordHash(userId uuid.UUID, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findById(userId)
+	if i < 0 {
+		return service.ErrNotFound
+	}
+	m.users[i].PasswordHash = hash
+	m.users[i].Version++
+	m.users[i].UpdatedAt = time.Now()
+	return nil
+}
+
+func (m *MemoryRepository) PassportOwner(organizationId uuid.UUID, serie, number int) uuid.UUID {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, usr := range m.users {
+		if !usr.DeletedAt.Valid && usr.OrganizationId == organizationId &&
+			usr.PassportSerie == serie && usr.PassportNumber == number {
+			return usr.UserId
+		}
+	}
+	return uuid.Nil
+}
+
+func (m *MemoryRepository) LoginExists(login string) bool {
+	_, err := m.ReadByLogin(login)
+	return err == nil
+}
+
+func (m *MemoryRepository) Exists(userId uuid.UUID) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.findById(userId) >= 0, nil
+}
+
+func (m *MemoryRepository) CountAdmins(organizationId uuid.UUID) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, usr := range m.users {
+		if !usr.DeletedAt.Valid && usr.OrganizationId == organizationId && usr.Role == RoleAdmin {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MemoryRepository) ReadDeleted(organizationId uuid.UUID) ([]FullUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var users []FullUser
+	for _, usr := range m.users {
+		if usr.DeletedAt.Valid && usr.OrganizationId == organizationId {
+			users = append(users, usr)
+		}
+	}
+	sort.SliceStable(users, func(i, j int) bool {
+		return users[i].DeletedAt.Time.After(users[j].DeletedAt.Time)
+	})
+	return users, nil
+}
+
+func (m *MemoryRepository) ReadDeletedBefore(t time.Time) ([]FullUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var users []FullUser
+	for _, usr := range m.users {
+		if usr.DeletedAt.Valid && usr.DeletedAt.Time.Before(t) {
+			users = append(users, usr)
+		}
+	}
+	return users, nil
+}
+
+func (m *MemoryRepository) Restore(organizationId, userId uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findDeleted(organizationId, userId)
+	if i < 0 {
+		return service.ErrNotFound
+	}
+
+	usr := m.users[i]
+	for _, other := range m.users {
+		if other.DeletedAt.Valid {
+			continue
+		}
+		if other.Login == usr.Login {
+			return service.ErrLoginExists
+		}
+		if other.OrganizationId == usr.OrganizationId &&
+			other.PassportSerie == usr.PassportSerie && other.PassportNumber == usr.PassportNumber {
+			return service.ErrPassportExists
+		}
+	}
+
+	m.users[i].DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+func (m *MemoryRepository) Purge(organizationId, userId uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findDeleted(organizationId, userId)
+	if i < 0 {
+		return service.ErrNotFound
+	}
+	m.users = append(m.users[:i], m.users[i+1:]...)
+	return nil
+}
+
+func (m *MemoryRepository) ReadPending() ([]FullUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var users []FullUser
+	for _, usr := range m.users {
+		if !usr.DeletedAt.Valid && usr.EnrichmentStatus == EnrichmentPending {
+			users = append(users, usr)
+		}
+	}
+	return users, nil
+}
+
+func (m *MemoryRepository) SetEnrichment(organizationId, userId uuid.UUID, result EnrichmentResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.find(organizationId, userId)
+	if i < 0 || m.users[i].EnrichmentStatus != EnrichmentPending {
+		return service.ErrNotFound
+	}
+
+	usr := &m.users[i]
+	usr.EnrichmentStatus = result.Status
+	usr.EnrichmentError = result.Error
+	usr.SubmittedPerson = nil
+	if result.Status == EnrichmentComplete {
+		usr.Name = result.Person.Name
+		usr.Surname = result.Person.Surname
+		usr.Patronymic = result.Person.Patronymic
+		usr.Address = result.Person.Address
+	}
+	usr.Version++
+	usr.UpdatedAt = time.Now()
+	return nil
+}
+
+func (m *MemoryRepository) UpdatePerson(organizationId, userId uuid.UUID, version int64, p enrichment.Person) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.findVersion(organizationId, userId, version)
+	if err != nil {
+		return err
+	}
+
+	usr := &m.users[i]
+	usr.Name, usr.Surname, usr.Patronymic, usr.Address = p.Name, p.Surname, p.Patronymic, p.Address
+	usr.EnrichmentStatus = EnrichmentComplete
+	usr.EnrichmentError = ""
+	usr.SubmittedPerson = nil
+	usr.Version++
+	usr.UpdatedAt = time.Now()
+	return nil
+}
+
+func (m *MemoryRepository) ReadBatch(afterId uint, limit int) ([]FullUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var users []FullUser
+	for _, usr := range m.users {
+		if !usr.DeletedAt.Valid && usr.ID > afterId {
+			users = append(users, usr)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+func (m *MemoryRepository) CreateOrganization(org *Organization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextId++
+	now := time.Now()
+	org.ID, org.CreatedAt, org.UpdatedAt = m.nextId, now, now
+	m.orgs = append(m.orgs, *org)
+	return nil
+}
+
+func (m *MemoryRepository) ReadOrganization(organizationId uuid.UUID) (Organization, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, org := range m.orgs {
+		if !org.DeletedAt.Valid && org.OrganizationId == organizationId {
+			return org, nil
+		}
+	}
+	return Organization{}, gorm.ErrRecordNotFound
+}
+
+func (m *MemoryRepository) ReadOrganizationByJoinCode(code string) (Organization, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, org := range m.orgs {
+		if !org.DeletedAt.Valid && org.JoinCode == code {
+			return org, nil
+		}
+	}
+	return Organization{}, gorm.ErrRecordNotFound
+}
+
+// Snapshot saves users and organizations and returns the function that
+// restores them, MemoryStore uses it to roll back failed transactions.
+func (m *MemoryRepository) Snapshot() (restore func()) {
+	m.mu.RLock()
+	users := append([]FullUser(nil), m.users...)
+	orgs := append([]Organization(nil), m.orgs...)
+	nextId := m.nextId
+	m.mu.RUnlock()
+
+	return func() {
+		m.mu.Lock()
+		m.users, m.orgs, m.nextId = users, orgs, nextId
+		m.mu.Unlock()
+	}
+}
+
+// LockOrganization only checks that the organization exists, transactions of
+// MemoryStore are serialized.
+func (m *MemoryRepository) LockOrganization(organizationId uuid.UUID) error {
+	_, err := m.ReadOrganization(organizationId)
+	return err
+}
+
+// find returns index of the live user of the organization or -1, uuid.Nil
+// organization matches none.
+func (m *MemoryRepository) find(organizationId, userId uuid.UUID) int {
+	i := m.findById(userId)
+	if i < 0 || organizationId == uuid.Nil || m.users[i].OrganizationId != organizationId {
+		return -1
+	}
+	return i
+}
+
+// findById returns index of the live user of any organization or -1.
+func (m *MemoryRepository) findById(userId uuid.UUID) int {
+	for i, usr := range m.users {
+		if !usr.DeletedAt.Valid && usr.UserId == userId {
+			return i
+		}
+	}
+	return -1
+}
+
+// findVersion finds the live user, a non-zero version must match the stored one.
+func (m *MemoryRepository) findVersion(organizationId, userId uuid.UUID, version int64) (int, error) {
+	i := m.find(organizationId, userId)
+	if version != 0 && (i < 0 || m.users[i].Version != version) {
+		return -1, service.ErrPreconditionFailed
+	}
+	if i < 0 {
+		return -1, service.ErrNotFound
+	}
+	return i, nil
+}
+
+func (m *MemoryRepository) findDeleted(organizationId, userId uuid.UUID) int {
+	for i, usr := range m.users {
+		if usr.DeletedAt.Valid && usr.UserId == userId && usr.OrganizationId == organizationId {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchFilters compares user fields against filters keyed by column names.
+func matchFilters(usr *FullUser, filters map[string]interface{}) bool {
+	columns := map[string]interface{}{
+		"passport_serie":  usr.PassportSerie,
+		"passport_number": usr.PassportNumber,
+		"name":            usr.Name,
+		"surname":         usr.Surname,
+		"patronymic":      usr.Patronymic,
+		"address":         usr.Address,
+		"login":           usr.Login,
+		"role":            usr.Role,
+		"manager_id":      usr.ManagerId,
+		"user_id":         usr.UserId,
+	}
+	for k, v := range filters {
+		if columns[k] != v {
+			return false
+		}
+	}
+	return true
+}
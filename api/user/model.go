@@ -1,115 +1,159 @@
 package user
 
 import (
-	"errors"
-	"fmt"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"time_tracker/api/enrichment"
 )
 
 type FullUser struct {
-	gorm.Model     `json:"-"`
-	PassportSerie  int       `json:"passportSerie"  extensions:"x-order=1"`
-	PassportNumber int       `json:"passportNumber" extensions:"x-order=2"`
-	Name           string    `json:"name" extensions:"x-order=3"`
-	Surname        string    `json:"surname" extensions:"x-order=4"`
-	Patronymic     string    `json:"patronymic" extensions:"x-order=5"`
-	Address        string    `json:"address" extensions:"x-order=6"`
-	UserId         uuid.UUID `json:"userId" extensions:"x-order=7"`
+	gorm.Model       `json:"-"`
+	PassportSerie    int       `json:"passportSerie"  extensions:"x-order=1"`
+	PassportNumber   int       `json:"passportNumber" extensions:"x-order=2"`
+	Name             string    `json:"name" extensions:"x-order=3"`
+	Surname          string    `json:"surname" extensions:"x-order=4"`
+	Patronymic       string    `json:"patronymic" extensions:"x-order=5"`
+	Address          string    `json:"address" extensions:"x-order=6"`
+	UserId           uuid.UUID `json:"userId" extensions:"x-order=7"`
+	Login            string    `json:"login" extensions:"x-order=8"`
+	Role             string    `json:"role" gorm:"default:member" example:"member" extensions:"x-order=9"`
+	ManagerId        uuid.UUID `json:"managerId" extensions:"x-order=10"`
+	OrganizationId   uuid.UUID `json:"organizationId" gorm:"index" extensions:"x-order=11"`
+	EnrichmentStatus string    `json:"enrichmentStatus" gorm:"not null;default:complete" example:"complete" enums:"complete,pending_enrichment,enrichment_failed" extensions:"x-order=12"`
+	EnrichmentError  string    `json:"enrichmentError,omitempty" extensions:"x-order=13"`
+	PasswordHash     string    `json:"-"`
+	Version          int64     `json:"-" gorm:"not null;default:1"`
+	// SubmittedPerson is the personal data sent on creation, it is kept while
+	// enrichment is pending so that providers using it can be retried.
+	SubmittedPerson *enrichment.Person `json:"-" gorm:"serializer:json"`
 }
 
 type NewUser struct {
-	PassportNumber string `json:"passportNumber" binding:"required"`
+	PassportNumber   string `json:"passportNumber" binding:"required" extensions:"x-order=1"`
+	Login            string `json:"login" binding:"required" extensions:"x-order=2"`
+	Password         string `json:"password" binding:"required" extensions:"x-order=3"`
+	OrganizationCode string `json:"organizationCode" binding:"required" extensions:"x-order=4"`
+	Name             string `json:"name,omitempty" extensions:"x-order=5"`
+	Surname          string `json:"surname,omitempty" extensions:"x-order=6"`
+	Patronymic       string `json:"patronymic,omitempty" extensions:"x-order=7"`
+	Address          string `json:"address,omitempty" extensions:"x-order=8"`
 }
 
-type UpdateUser struct {
-	PassportSerie  int       `json:"passportSerie" extensions:"x-order=1"`
-	PassportNumber int       `json:"passportNumber" extensions:"x-order=2"`
-	Name           string    `json:"name" extensions:"x-order=3"`
-	Surname        string    `json:"surname" extensions:"x-order=4"`
-	Patronymic     string    `json:"patronymic" extensions:"x-order=5"`
-	Address        string    `json:"address" extensions:"x-order=6"`
-	UserId         uuid.UUID `json:"-"`
+// Enrichment statuses: personal data of a pending user is being requested in
+// the background, EnrichmentError of a failed one explains why.
+const (
+	EnrichmentComplete = "complete"
+	EnrichmentPending  = "pending_enrichment"
+	EnrichmentFailed   = "enrichment_failed"
+)
+
+// EnrichmentResult completes a user created with pending enrichment. Person
+// is stored only when Status is EnrichmentComplete.
+type EnrichmentResult struct {
+	Status string
+	Error  string
+	Person enrichment.Person
 }
 
-func (f *FullUser) TableName() string {
-	return "users"
+// FieldChange is a personal data field which differs from the external source.
+type FieldChange struct {
+	Field string `json:"field" example:"address" extensions:"x-order=1"`
+	Old   string `json:"old" extensions:"x-order=2"`
+	New   string `json:"new" extensions:"x-order=3"`
 }
 
-func (f *FullUser) Create() error {
-	var err error
+// RefreshResult lists changes of the refreshed user, Applied is false in
+// dry-run mode or if there was nothing to change.
+type RefreshResult struct {
+	UserId  uuid.UUID     `json:"userId" extensions:"x-order=1"`
+	Changes []FieldChange `json:"changes" extensions:"x-order=2"`
+	Applied bool          `json:"applied" extensions:"x-order=3"`
+}
 
-	err = DB.Create(f).Error
-	if err != nil {
-		return err
-	}
+const (
+	TasksBlock    = "block"
+	TasksCascade  = "cascade"
+	TasksReassign = "reassign"
+)
 
-	return nil
+// DeletePolicy chooses what happens to tasks of the deleted user: block
+// refuses to delete a user with tasks, cascade deletes them together with the
+// user, reassign hands them over to ReassignTo.
+type DeletePolicy struct {
+	Tasks      string
+	ReassignTo uuid.UUID
 }
 
-func (f *FullUser) ReadOne() error {
-	var err error
-	err = DB.Where("user_id = ?", f.UserId).First(f).Error
-	if err != nil {
-		return err
-	}
-	return nil
+type Organization struct {
+	gorm.Model     `json:"-"`
+	OrganizationId uuid.UUID `json:"organizationId" extensions:"x-order=1"`
+	Name           string    `json:"name" extensions:"x-order=2"`
+	JoinCode       string    `json:"joinCode,omitempty" gorm:"uniqueIndex" extensions:"x-order=3"`
 }
 
-func (f *FullUser) ReadMany(filters map[string]interface{}, params map[string]int) ([]FullUser, error) {
-	var users []FullUser
-
-	query := DB.Model(&FullUser{})
-
-	for k, v := range filters {
-		query = query.Where(fmt.Sprintf("%s = ?", k), v)
-	}
+// NewOrganization is created together with its first admin by the
+// create-organization command.
+type NewOrganization struct {
+	Name  string
+	Admin NewAdmin
+}
 
-	query = query.
-		Offset((params["page"] - 1) * params["per_page"]).
-		Limit(params["per_page"])
+// NewAdmin is the first user of a new organization, personal data is used
+// the same way as for NewUser.
+type NewAdmin struct {
+	PassportNumber string
+	Login          string
+	Password       string
+	Name           string
+	Surname        string
+	Patronymic     string
+	Address        string
+}
 
-	err := query.Find(&users).Error
-	if err != nil {
-		return nil, err
-	}
+type Credentials struct {
+	Login    string `json:"login" extensions:"x-order=1"`
+	Password string `json:"password" extensions:"x-order=2"`
+}
 
-	return users, nil
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
-func (u *UpdateUser) Update() error {
-	result := DB.Model(&FullUser{}).Where("user_id = ?", u.UserId).Updates(u)
+type UpdateUser struct {
+	PassportSerie  int       `json:"passportSerie" extensions:"x-order=1"`
+	PassportNumber int       `json:"passportNumber" extensions:"x-order=2"`
+	Name           string    `json:"name" extensions:"x-order=3"`
+	Surname        string    `json:"surname" extensions:"x-order=4"`
+	Patronymic     string    `json:"patronymic" extensions:"x-order=5"`
+	Address        string    `json:"address" extensions:"x-order=6"`
+	Role           string    `json:"role" example:"member" extensions:"x-order=7"`
+	ManagerId      uuid.UUID `json:"managerId" extensions:"x-order=8"`
+	UserId         uuid.UUID `json:"-"`
+	OrganizationId uuid.UUID `json:"-" gorm:"-"`
+	Version        int64     `json:"-" gorm:"-"`
+}
 
-	if result.Error != nil {
-		return result.Error
-	}
+func (f *FullUser) TableName() string {
+	return "users"
+}
 
-	if result.RowsAffected == 0 {
-		return errors.New("404")
+func (f *FullUser) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
 	}
-
+	f.PasswordHash = string(hash)
 	return nil
 }
 
-func (f *FullUser) Delete() error {
-	result := DB.Where("user_id = ?", f.UserId).Delete(f)
-
-	if result.Error != nil {
-		return result.Error
+func (f *FullUser) CheckPassword(password string) bool {
+	if f.PasswordHash == "" {
+		return false
 	}
-
-	if result.RowsAffected == 0 {
-		return errors.New("user not found")
-	}
-	return nil
+	return bcrypt.CompareHashAndPassword([]byte(f.PasswordHash), []byte(password)) == nil
 }
 
-func exists(serie, number int) uuid.UUID {
-	var usr FullUser
-	result := DB.Where("passport_serie = ? AND passport_number = ?", serie, number).First(&usr)
-	if result.Error != nil {
-		return uuid.Nil
-	} else {
-		return usr.UserId
-	}
+func (o *Organization) TableName() string {
+	return "organizations"
 }
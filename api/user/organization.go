@@ -0,0 +1,105 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/service"
+	"time_tracker/api/validation"
+)
+
+// CreateOrganization creates the organization and its first admin in one
+// transaction, so an organization never exists without an admin. Personal
+// data of the admin is requested from enricher.
+func CreateOrganization(ctx context.Context, store Store, enricher enrichment.Provider, newOrg NewOrganization) (Organization, FullUser, error) {
+	serie, number, err := newOrg.validate()
+	if err != nil {
+		return Organization{}, FullUser{}, err
+	}
+
+	person, err := enricher.Enrich(ctx, enrichment.Request{
+		PassportSerie:  serie,
+		PassportNumber: number,
+		Submitted:      newOrg.Admin.person(),
+	})
+	if err != nil {
+		var sentinel *service.Error
+		if !errors.As(err, &sentinel) {
+			err = fmt.Errorf("%w: %w", service.ErrExternalAPI, err)
+		}
+		return Organization{}, FullUser{}, err
+	}
+
+	err = person.ValidateRequiredFields()
+	if err != nil {
+		return Organization{}, FullUser{}, err
+	}
+
+	code, err := generateJoinCode()
+	if err != nil {
+		return Organization{}, FullUser{}, fmt.Errorf("%w: %w", service.ErrInternal, err)
+	}
+
+	org := Organization{
+		OrganizationId: uuid.New(),
+		Name:           newOrg.Name,
+		JoinCode:       code,
+	}
+
+	admin := FullUser{
+		PassportSerie:    serie,
+		PassportNumber:   number,
+		Name:             person.Name,
+		Surname:          person.Surname,
+		Patronymic:       person.Patronymic,
+		Address:          person.Address,
+		UserId:           uuid.New(),
+		Login:            newOrg.Admin.Login,
+		Role:             RoleAdmin,
+		OrganizationId:   org.OrganizationId,
+		EnrichmentStatus: EnrichmentComplete,
+	}
+
+	err = admin.SetPassword(newOrg.Admin.Password)
+	if err != nil {
+		return Organization{}, FullUser{}, fmt.Errorf("%w: %w", service.ErrInternal, err)
+	}
+
+	err = store.Transaction(func(users UserRepository, _ OwnedTasks) error {
+		if users.LoginExists(admin.Login) {
+			return service.ErrLoginExists
+		}
+
+		err := users.CreateOrganization(&org)
+		if err != nil {
+			return err
+		}
+		return users.Create(&admin)
+	})
+	if err != nil {
+		return Organization{}, FullUser{}, err
+	}
+	return org, admin, nil
+}
+
+func (n *NewOrganization) validate() (int, int, error) {
+	v := validation.New()
+	v.Required("name", n.Name)
+
+	admin := v.At("/admin")
+	serie, number := validatePassportNumber(admin, "passportNumber", n.Admin.PassportNumber)
+	validateCredentials(admin, n.Admin.Login, n.Admin.Password)
+	return serie, number, v.Err()
+}
+
+// person returns the personal data sent with the request.
+func (n *NewAdmin) person() enrichment.Person {
+	return enrichment.Person{
+		Name:       n.Name,
+		Patronymic: n.Patronymic,
+		Surname:    n.Surname,
+		Address:    n.Address,
+	}
+}
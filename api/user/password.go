@@ -0,0 +1,34 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time_tracker/api/service"
+	"time_tracker/api/validation"
+)
+
+// SetPassword replaces the password of the user with the login. It's meant
+// for operators, users adopted from legacy databases have no password.
+func SetPassword(users UserRepository, login, password string) error {
+	v := validation.New()
+	validateCredentials(v, login, password)
+	err := v.Err()
+	if err != nil {
+		return err
+	}
+
+	usr, err := users.ReadByLogin(login)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: no user with login %q", service.ErrNotFound, login)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = usr.SetPassword(password)
+	if err != nil {
+		return fmt.Errorf("%w: %w", service.ErrInternal, err)
+	}
+	return users.SetPasswordHash(usr.UserId, usr.PasswordHash)
+}
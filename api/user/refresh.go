@@ -0,0 +1,166 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"time"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/service"
+)
+
+// refreshBatchSize is the number of users read at once by RefreshAll.
+const refreshBatchSize = 100
+
+// refreshUser requests fresh personal data of usr and compares it with the
+// stored one. Changes are applied unless dryRun, a non-zero version must
+// match the stored user version. Users with failed enrichment are completed.
+func refreshUser(ctx context.Context, users UserRepository, provider enrichment.Provider, usr *FullUser, version int64, dryRun bool) (RefreshResult, error) {
+	stored := usr.person()
+	person, err := provider.Enrich(ctx, enrichment.Request{
+		PassportSerie:  usr.PassportSerie,
+		PassportNumber: usr.PassportNumber,
+		Submitted:      stored,
+		Fresh:          true,
+	})
+	if err != nil {
+		var sentinel *service.Error
+		if !errors.As(err, &sentinel) {
+			err = fmt.Errorf("%w: %w", service.ErrExternalAPI, err)
+		}
+		return RefreshResult{}, err
+	}
+
+	err = person.ValidateRequiredFields()
+	if err != nil {
+		return RefreshResult{}, err
+	}
+
+	result := RefreshResult{UserId: usr.UserId, Changes: diffPerson(stored, person)}
+	if dryRun || (len(result.Changes) == 0 && usr.EnrichmentStatus == EnrichmentComplete) {
+		return result, nil
+	}
+
+	err = users.UpdatePerson(usr.OrganizationId, usr.UserId, version, person)
+	if err != nil {
+		return RefreshResult{}, err
+	}
+	result.Applied = true
+	return result, nil
+}
+
+// diffPerson lists fields of fetched differing from stored, never nil.
+func diffPerson(stored, fetched enrichment.Person) []FieldChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"name", stored.Name, fetched.Name},
+		{"surname", stored.Surname, fetched.Surname},
+		{"patronymic", stored.Patronymic, fetched.Patronymic},
+		{"address", stored.Address, fetched.Address},
+	}
+
+	changes := []FieldChange{}
+	for _, f := range fields {
+		if f.old != f.new {
+			changes = append(changes, FieldChange{Field: f.name, Old: f.old, New: f.new})
+		}
+	}
+	return changes
+}
+
+// RefreshReport sums up a bulk refresh.
+type RefreshReport struct {
+	Checked int
+	Changed int
+	Failed  int
+}
+
+// Refresher re-fetches personal data of users of all organizations every
+// Interval. Users waiting for enrichment are skipped.
+type Refresher struct {
+	Users    UserRepository
+	Provider enrichment.Provider
+	Interval time.Duration
+}
+
+func NewRefresher(users UserRepository, provider enrichment.Provider, interval time.Duration) *Refresher {
+	return &Refresher{Users: users, Provider: provider, Interval: interval}
+}
+
+// Run refreshes all users every Interval until ctx is cancelled.
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	log.WithField("interval", r.Interval).Info("User refresh started")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := r.RefreshAll(ctx, false)
+		if err != nil && ctx.Err() == nil {
+			log.Error("User refresh: ", err)
+		}
+	}
+}
+
+// RefreshAll refreshes every user, changes are only reported if dryRun.
+// Failures of single users are logged and counted, the run stops when ctx
+// is cancelled or the external API becomes unavailable.
+func (r *Refresher) RefreshAll(ctx context.Context, dryRun bool) (RefreshReport, error) {
+	var report RefreshReport
+	var afterId uint
+
+	for {
+		batch, err := r.Users.ReadBatch(afterId, refreshBatchSize)
+		if err != nil {
+			return report, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		afterId = batch[len(batch)-1].ID
+
+		for i := range batch {
+			usr := &batch[i]
+			if usr.EnrichmentStatus == EnrichmentPending {
+				continue
+			}
+
+			report.Checked++
+			result, err := refreshUser(ctx, r.Users, r.Provider, usr, usr.Version, dryRun)
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			if errors.Is(err, service.ErrExternalUnavailable) {
+				report.Failed++
+				return report, err
+			}
+
+			logger := log.WithField("user", usr.UserId)
+			if err != nil {
+				report.Failed++
+				logger.Warn("User refresh failed: ", err)
+				continue
+			}
+			if len(result.Changes) > 0 {
+				report.Changed++
+				logger.WithFields(log.Fields{"changes": result.Changes, "applied": result.Applied}).Info("User data changed")
+			}
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"checked": report.Checked,
+		"changed": report.Changed,
+		"failed":  report.Failed,
+		"dry_run": dryRun,
+	}).Info("Users refreshed")
+	return report, nil
+}
@@ -0,0 +1,417 @@
+package user
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/service"
+	"time_tracker/db"
+)
+
+// UserRepository stores users and their organizations. Methods taking
+// organizationId never return records of other organizations. Update and
+// Delete increment the user version; a non-zero version makes them
+// conditional and ErrPreconditionFailed is returned on mismatch.
+type UserRepository interface {
+	Create(usr *FullUser) error
+	ReadOne(organizationId, userId uuid.UUID) (FullUser, error)
+	// ReadById looks the user up in any organization, it is meant only for
+	// the authenticated user whose organization isn't known yet.
+	ReadById(userId uuid.UUID) (FullUser, error)
+	ReadByLogin(login string) (FullUser, error)
+	ReadMany(organizationId uuid.UUID, filters map[string]interface{}, params map[string]int) ([]FullUser, error)
+	Update(usr *UpdateUser) error
+	Delete(organizationId, userId uuid.UUID, version int64) error
+	// PassportOwner returns UUID of the user holding the passport or uuid.Nil.
+	PassportOwner(organizationId uuid.UUID, serie, number int) uuid.UUID
+	LoginExists(login string) bool
+	// Exists reports whether the live user exists in any organization.
+	Exists(userId uuid.UUID) (bool, error)
+	CountAdmins(organizationId uuid.UUID) (int64, error)
+	// SetPasswordHash replaces the password hash of the live user.
+	SetPasswordHash(userId uuid.UUID, hash string) error
+
+	// ReadDeleted lists soft-deleted users of the organization, most recently deleted first.
+	ReadDeleted(organizationId uuid.UUID) ([]FullUser, error)
+	// ReadDeletedBefore lists users of all organizations soft-deleted before t.
+	ReadDeletedBefore(t time.Time) ([]FullUser, error)
+	Restore(organizationId, userId uuid.UUID) error
+	// Purge permanently deletes the soft-deleted user.
+	Purge(organizationId, userId uuid.UUID) error
+
+	// ReadPending lists users of all organizations waiting for enrichment,
+	// oldest first.
+	ReadPending() ([]FullUser, error)
+	// SetEnrichment stores the result of enrichment of the pending user and
+	// drops the personal data submitted on creation.
+	SetEnrichment(organizationId, userId uuid.UUID, result EnrichmentResult) error
+	// UpdatePerson replaces personal data, empty fields included, and completes enrichment.
+	UpdatePerson(organizationId, userId uuid.UUID, version int64, p enrichment.Person) error
+	// ReadBatch lists up to limit users of all organizations with id greater than afterId, ordered by id.
+	ReadBatch(afterId uint, limit int) ([]FullUser, error)
+
+	CreateOrganization(org *Organization) error
+	ReadOrganization(organizationId uuid.UUID) (Organization, error)
+	ReadOrganizationByJoinCode(code string) (Organization, error)
+	// LockOrganization locks the organization until the end of the running
+	// transaction, changes of its admins are serialized this way.
+	LockOrganization(organizationId uuid.UUID) error
+}
+
+// OwnedTasks is the part of task storage used when the owner is deleted.
+// It is implemented by the task package, which depends on this one.
+type OwnedTasks interface {
+	CountByOwner(ownerId uuid.UUID) (int64, error)
+	CountDeletedByOwner(ownerId uuid.UUID) (int64, error)
+	DeleteByOwner(ownerId uuid.UUID) error
+	ReassignOwner(ownerId, newOwnerId uuid.UUID) error
+	// PurgeByOwner permanently deletes soft-deleted tasks of the owner.
+	PurgeByOwner(ownerId uuid.UUID) error
+	// RestoreByOwner restores soft-deleted tasks of the owner.
+	RestoreByOwner(ownerId uuid.UUID) error
+}
+
+// Store runs fn in one transaction over users and their tasks.
+type Store interface {
+	Transaction(fn func(users UserRepository, tasks OwnedTasks) error) error
+}
+
+type GormRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRepository(db *gorm.DB) *GormRepository {
+	return &GormRepository{db: db}
+}
+
+func (g *GormRepository) Create(usr *FullUser) error {
+	err := g.db.Create(usr).Error
+	if err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+func (g *GormRepository) ReadOne(organizationId, userId uuid.UUID) (FullUser, error) {
+	// users without an organization belong to no tenant and can't be read by one
+	if organizationId == uuid.Nil {
+		return FullUser{}, gorm.ErrRecordNotFound
+	}
+
+	var usr FullUser
+	err := g.db.Where("user_id = ? AND organization_id = ?", userId, organizationId).First(&usr).Error
+	if err != nil {
+		return FullUser{}, err
+	}
+	return usr, nil
+}
+
+func (g *GormRepository) ReadById(userId uuid.UUID) (FullUser, error) {
+	var usr FullUser
+	err := g.db.Where("user_id = ?", userId).First(&usr).Error
+	if err != nil {
+		return FullUser{}, err
+	}
+	return usr, nil
+}
+
+func (g *GormRepository) ReadByLogin(login string) (FullUser, error) {
+	var usr FullUser
+	err := g.db.Where("login = ?", login).First(&usr).Error
+	if err != nil {
+		return FullUser{}, err
+	}
+	return usr, nil
+}
+
+func (g *GormRepository) ReadMany(organizationId uuid.UUID, filters map[string]interface{}, params map[string]int) ([]FullUser, error) {
+	var users []FullUser
+
+	query := g.db.Model(&FullUser{}).Where("organization_id = ?", organizationId)
+
+	for k, v := range filters {
+		query = query.Where(fmt.Sprintf("%s = ?", k), v)
+	}
+
+	query = query.
+		Offset((params["page"] - 1) * params["per_page"]).
+		Limit(params["per_page"])
+
+	err := query.Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (g *GormRepository) Update(usr *UpdateUser) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		err := bumpVersion(tx, usr.OrganizationId, usr.UserId, usr.Version)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&FullUser{}).
+			Where("user_id = ? AND organization_id = ?", usr.UserId, usr.OrganizationId).
+			Updates(usr).Error
+		if err != nil {
+			return translateError(err)
+		}
+		return nil
+	})
+}
+
+func (g *GormRepository) Delete(organizationId, userId uuid.UUID, version int64) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		err := bumpVersion(tx, organizationId, userId, version)
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND organization_id = ?", userId, organizationId).Delete(&FullUser{}).Error
+	})
+}
+
+func (g *GormRepository) SetPasswordHash(userId uuid.UUID, hash string) error {
+	result := g.db.Model(&FullUser{}).Where("user_id = ?", userId).
+		Updates(map[string]interface{}{"password_hash": hash, "version": gorm.Expr("version + 1")})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return service.ErrNotFound
+	}
+	return nil
+}
+
+func (g *GormRepository) PassportOwner(organizationId uuid.UUID, serie, number int) uuid.UUID {
+	var usr FullUser
+	result := g.db.Where("organization_id = ? AND passport_serie = ? AND passport_number = ?",
+		organizationId, serie, number).First(&usr)
+	if result.Error != nil {
+		return uuid.Nil
+	}
+	return usr.UserId
+}
+
+func (g *GormRepository) LoginExists(login string) bool {
+	var usr FullUser
+	result := g.db.Where("login = ?", login).First(&usr)
+	return result.Error == nil
+}
+
+func (g *GormRepository) Exists(userId uuid.UUID) (bool, error) {
+	var count int64
+	err := g.db.Model(&FullUser{}).Where("user_id = ?", userId).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (g *GormRepository) CountAdmins(organizationId uuid.UUID) (int64, error) {
+	var count int64
+	err := g.db.Model(&FullUser{}).Where("organization_id = ? AND role = ?", organizationId, RoleAdmin).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (g *GormRepository) ReadDeleted(organizationId uuid.UUID) ([]FullUser, error) {
+	var users []FullUser
+	err := g.db.Unscoped().
+		Where("organization_id = ? AND deleted_at IS NOT NULL", organizationId).
+		Order("deleted_at DESC").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (g *GormRepository) ReadDeletedBefore(t time.Time) ([]FullUser, error) {
+	var users []FullUser
+	err := g.db.Unscoped().Where("deleted_at < ?", t).Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (g *GormRepository) Restore(organizationId, userId uuid.UUID) error {
+	result := g.db.Unscoped().Model(&FullUser{}).
+		Where("user_id = ? AND organization_id = ? AND deleted_at IS NOT NULL", userId, organizationId).
+		Update("deleted_at", nil)
+
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return service.ErrNotFound
+	}
+	return nil
+}
+
+func (g *GormRepository) Purge(organizationId, userId uuid.UUID) error {
+	result := g.db.Unscoped().
+		Where("user_id = ? AND organization_id = ? AND deleted_at IS NOT NULL", userId, organizationId).
+		Delete(&FullUser{})
+
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return service.ErrNotFound
+	}
+	return nil
+}
+
+func (g *GormRepository) ReadPending() ([]FullUser, error) {
+	var users []FullUser
+	err := g.db.Where("enrichment_status = ?", EnrichmentPending).Order("id").Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (g *GormRepository) SetEnrichment(organizationId, userId uuid.UUID, result EnrichmentResult) error {
+	values := map[string]interface{}{
+		"enrichment_status": result.Status,
+		"enrichment_error":  result.Error,
+		"submitted_person":  nil,
+		"version":           gorm.Expr("version + 1"),
+	}
+	if result.Status == EnrichmentComplete {
+		values["name"] = result.Person.Name
+		values["surname"] = result.Person.Surname
+		values["patronymic"] = result.Person.Patronymic
+		values["address"] = result.Person.Address
+	}
+
+	res := g.db.Model(&FullUser{}).
+		Where("user_id = ? AND organization_id = ? AND enrichment_status = ?", userId, organizationId, EnrichmentPending).
+		Updates(values)
+
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return service.ErrNotFound
+	}
+	return nil
+}
+
+func (g *GormRepository) UpdatePerson(organizationId, userId uuid.UUID, version int64, p enrichment.Person) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		err := bumpVersion(tx, organizationId, userId, version)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&FullUser{}).
+			Where("user_id = ? AND organization_id = ?", userId, organizationId).
+			Updates(map[string]interface{}{
+				"name":              p.Name,
+				"surname":           p.Surname,
+				"patronymic":        p.Patronymic,
+				"address":           p.Address,
+				"enrichment_status": EnrichmentComplete,
+				"enrichment_error":  "",
+				"submitted_person":  nil,
+			}).Error
+	})
+}
+
+func (g *GormRepository) ReadBatch(afterId uint, limit int) ([]FullUser, error) {
+	var users []FullUser
+	err := g.db.Where("id > ?", afterId).Order("id").Limit(limit).Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (g *GormRepository) CreateOrganization(org *Organization) error {
+	err := g.db.Create(org).Error
+	if err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+func (g *GormRepository) ReadOrganization(organizationId uuid.UUID) (Organization, error) {
+	var org Organization
+	err := g.db.Where("organization_id = ?", organizationId).First(&org).Error
+	if err != nil {
+		return Organization{}, err
+	}
+	return org, nil
+}
+
+func (g *GormRepository) ReadOrganizationByJoinCode(code string) (Organization, error) {
+	var org Organization
+	err := g.db.Where("join_code = ?", code).First(&org).Error
+	if err != nil {
+		return Organization{}, err
+	}
+	return org, nil
+}
+
+func (g *GormRepository) LockOrganization(organizationId uuid.UUID) error {
+	var org Organization
+	// SQLite has no row locks, its single connection serializes transactions anyway
+	return g.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ?", organizationId).First(&org).Error
+}
+
+// bumpVersion increments the version of the live user, a non-zero version
+// makes it conditional on the stored one.
+func bumpVersion(tx *gorm.DB, organizationId, userId uuid.UUID, version int64) error {
+	query := tx.Model(&FullUser{}).Where("user_id = ? AND organization_id = ?", userId, organizationId)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	result := query.UpdateColumn("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if version != 0 {
+			return service.ErrPreconditionFailed
+		}
+		return service.ErrNotFound
+	}
+	return nil
+}
+
+// translateError maps constraint violations to API errors, unique indexes
+// are the final guard against concurrent requests passing the checks.
+func translateError(err error) error {
+	c, ok := db.AsConstraintError(err)
+	if !ok {
+		return err
+	}
+
+	switch c.Constraint {
+	case db.IndexUsersPassport:
+		return service.ErrPassportExists
+	case db.IndexUsersLogin:
+		return service.ErrLoginExists
+	case db.FKTasksOwner:
+		return service.ErrUserHasTasks
+	default:
+		return fmt.Errorf("%w: %s", service.ErrConflict, c.Error())
+	}
+}
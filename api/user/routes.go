@@ -1,11 +1,18 @@
 package user
 
-import "net/http"
+import (
+	"net/http"
+	"time_tracker/api/auth"
+)
 
-func AddRoutes(router *http.ServeMux) {
-	router.HandleFunc("POST /api/v1/user", CreateUserHandler)
-	router.HandleFunc("GET /api/v1/user/{uuid}", ReadUserByIDHandler)
-	router.HandleFunc("GET /api/v1/user", ReadManyHandler)
-	router.HandleFunc("PUT /api/v1/user/{uuid}", UpdateUserHandler)
-	router.HandleFunc("DELETE /api/v1/user/{uuid}", DeleteUserHandler)
+func (h *Handler) AddRoutes(router *http.ServeMux) {
+	router.HandleFunc("POST /api/v1/user", h.CreateUserHandler)
+	router.HandleFunc("GET /api/v1/user/{uuid}", auth.Scoped(auth.ScopeUsersRead, h.ReadUserByIDHandler))
+	router.HandleFunc("GET /api/v1/user", auth.Scoped(auth.ScopeUsersRead, h.ReadManyHandler))
+	router.HandleFunc("PUT /api/v1/user/{uuid}", auth.Scoped(auth.ScopeUsersWrite, h.UpdateUserHandler))
+	router.HandleFunc("DELETE /api/v1/user/{uuid}", auth.Scoped(auth.ScopeUsersWrite, h.DeleteUserHandler))
+	router.HandleFunc("POST /api/v1/user/{uuid}/refresh", auth.Scoped(auth.ScopeUsersWrite, h.RefreshUserHandler))
+	router.HandleFunc("POST /api/v1/auth/login", h.LoginHandler)
+	router.HandleFunc("POST /api/v1/auth/refresh", h.RefreshHandler)
+	router.HandleFunc("GET /api/v1/organization", h.ReadOrganizationHandler)
 }
@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"time_tracker/api/service"
+)
+
+const (
+	RuleRequired  = "required"
+	RuleFormat    = "format"
+	RuleLength    = "length"
+	RuleMinLength = "min_length"
+	RuleMaxItems  = "max_items"
+	RuleOneOf     = "one_of"
+	RuleExists    = "exists"
+)
+
+// Validator collects every field error instead of stopping at the first one.
+// Validators returned by At share errors with their parent.
+type Validator struct {
+	prefix string
+	errs   *service.FieldErrors
+}
+
+func New() *Validator {
+	return &Validator{errs: &service.FieldErrors{}}
+}
+
+// At returns a validator for a nested object, path is a JSON pointer
+// relative to the current one, e.g. "/operations/2".
+func (v *Validator) At(path string) *Validator {
+	return &Validator{prefix: v.prefix + path, errs: v.errs}
+}
+
+func (v *Validator) Add(field, rule, message string) {
+	*v.errs = append(*v.errs, service.FieldError{
+		Field:   field,
+		Path:    v.prefix + "/" + field,
+		Rule:    rule,
+		Message: message,
+	})
+}
+
+// Check adds the error if ok is false and returns ok.
+func (v *Validator) Check(ok bool, field, rule, message string) bool {
+	if !ok {
+		v.Add(field, rule, message)
+	}
+	return ok
+}
+
+func (v *Validator) Required(field, value string) bool {
+	return v.Check(strings.TrimSpace(value) != "", field, RuleRequired,
+		fmt.Sprintf("%s field can't be empty", field))
+}
+
+func (v *Validator) MinLength(field, value string, min int) bool {
+	return v.Check(len(value) >= min, field, RuleMinLength,
+		fmt.Sprintf("%s must be at least %d characters long", field, min))
+}
+
+func (v *Validator) OneOf(field, value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	v.Add(field, RuleOneOf, fmt.Sprintf("unknown %s %q, use one of: %s", field, value, strings.Join(allowed, ", ")))
+	return false
+}
+
+func (v *Validator) Valid() bool {
+	return len(*v.errs) == 0
+}
+
+// Err returns service.FieldErrors with all collected errors or nil.
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+	return *v.errs
+}
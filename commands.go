@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/external"
+	"time_tracker/api/external/mock"
+	"time_tracker/api/task"
+	"time_tracker/api/user"
+	"time_tracker/config"
+	"time_tracker/db"
+)
+
+const usage = `usage: time_tracker [command]
+
+Without a command the API server is started.
+
+Commands:
+  migrate up        apply all pending migrations
+  migrate down [n]  roll back the last n migrations, 1 by default
+  migrate status    list migrations and whether they are applied
+  create-organization
+                    create an organization and its admin, see create-organization -h
+  set-password -login LOGIN
+                    set the password of the user read from stdin
+  refresh           re-fetch personal data of all users from the enrichment provider
+  refresh --dry-run only report changes of personal data
+  mock-external     serve the people info API from fixtures, see mock-external -h
+  contract-external check the people info API against its contract, see contract-external -h`
+
+// runCommand executes a command line subcommand instead of the server.
+func runCommand(c *config.Config, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return migrateCommand(c, args[1:])
+	case "create-organization":
+		return createOrganizationCommand(c, args[1:])
+	case "set-password":
+		return setPasswordCommand(c, args[1:])
+	case "refresh":
+		return refreshCommand(c, args[1:])
+	case "mock-external":
+		return mockExternalCommand(args[1:])
+	case "contract-external":
+		return contractExternalCommand(args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func migrateCommand(c *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate: missing action\n" + usage)
+	}
+
+	DB := db.Connect(c, DBSetLogLevel(c.Config.DBLogLevel))
+
+	switch args[0] {
+	case "up":
+		done, err := db.MigrateUp(DB)
+		fmt.Printf("%d migration(s) applied\n", len(done))
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("migrate down: invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		done, err := db.MigrateDown(DB, steps)
+		fmt.Printf("%d migration(s) rolled back\n", len(done))
+		return err
+
+	case "status":
+		status, err := db.Status(DB)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range status {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format("02-01-2006 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("migrate: unknown action %q\n%s", args[0], usage)
+	}
+}
+
+// createOrganizationCommand is the only way to create organizations, the
+// admin password is read from the first line of stdin to keep it out of the
+// shell history.
+func createOrganizationCommand(c *config.Config, args []string) error {
+	flags := flag.NewFlagSet("create-organization", flag.ContinueOnError)
+	var newOrg user.NewOrganization
+	flags.StringVar(&newOrg.Name, "name", "", "organization name")
+	flags.StringVar(&newOrg.Admin.Login, "login", "", "admin login")
+	flags.StringVar(&newOrg.Admin.PassportNumber, "passport", "", "admin passport in format '1234 567890'")
+	flags.StringVar(&newOrg.Admin.Name, "admin-name", "", "admin name, used by the none enrichment provider")
+	flags.StringVar(&newOrg.Admin.Surname, "admin-surname", "", "admin surname, used by the none enrichment provider")
+	flags.StringVar(&newOrg.Admin.Patronymic, "admin-patronymic", "", "admin patronymic, used by the none enrichment provider")
+	flags.StringVar(&newOrg.Admin.Address, "admin-address", "", "admin address, used by the none enrichment provider")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: create-organization -name NAME -login LOGIN -passport '1234 567890' < password")
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("create-organization: reading admin password: %w", err)
+	}
+	newOrg.Admin.Password = strings.TrimRight(password, "\r\n")
+
+	DB := db.Connect(c, DBSetLogLevel(c.Config.DBLogLevel))
+	err = db.CheckSchema(DB)
+	if err != nil {
+		return err
+	}
+
+	enricher, err := newEnrichment(c, DB)
+	if err != nil {
+		return err
+	}
+
+	org, admin, err := user.CreateOrganization(context.Background(), task.NewGormStore(DB), enricher.Provider, newOrg)
+	if err != nil {
+		return fmt.Errorf("create-organization: %w", err)
+	}
+
+	fmt.Printf("Organization %q created\n", org.Name)
+	fmt.Printf("organizationId: %s\njoinCode:       %s\nadmin login:    %s\n", org.OrganizationId, org.JoinCode, admin.Login)
+	return nil
+}
+
+func setPasswordCommand(c *config.Config, args []string) error {
+	flags := flag.NewFlagSet("set-password", flag.ContinueOnError)
+	login := flags.String("login", "", "login of the user")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: set-password -login LOGIN < password")
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("set-password: reading password: %w", err)
+	}
+
+	DB := db.Connect(c, DBSetLogLevel(c.Config.DBLogLevel))
+	err = db.CheckSchema(DB)
+	if err != nil {
+		return err
+	}
+
+	err = user.SetPassword(user.Init(DB), *login, strings.TrimRight(password, "\r\n"))
+	if err != nil {
+		return fmt.Errorf("set-password: %w", err)
+	}
+
+	fmt.Printf("Password of %q set\n", *login)
+	return nil
+}
+
+func refreshCommand(c *config.Config, args []string) error {
+	dryRun := false
+	for _, arg := range args {
+		if arg != "--dry-run" {
+			return fmt.Errorf("refresh: unknown argument %q\n%s", arg, usage)
+		}
+		dryRun = true
+	}
+
+	DB := db.Connect(c, DBSetLogLevel(c.Config.DBLogLevel))
+	err := db.CheckSchema(DB)
+	if err != nil {
+		return err
+	}
+
+	enricher, err := newEnrichment(c, DB)
+	if err != nil {
+		return err
+	}
+
+	refresher := user.NewRefresher(user.Init(DB), enricher.Provider, 0)
+	report, err := refresher.RefreshAll(context.Background(), dryRun)
+	fmt.Printf("%d user(s) checked, %d changed, %d failed\n", report.Checked, report.Changed, report.Failed)
+	return err
+}
+
+func mockExternalCommand(args []string) error {
+	flags := flag.NewFlagSet("mock-external", flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:9001", "address to listen on")
+	fixtures := flags.String("fixtures", "fixtures/people.json", "*.json or *.csv file or a directory of them")
+	var mc mock.Config
+	flags.DurationVar(&mc.Latency, "latency", 0, "delay of every response")
+	flags.DurationVar(&mc.Jitter, "jitter", 0, "random extra delay up to this value")
+	flags.Float64Var(&mc.ErrorRate, "error-rate", 0, "share of requests failing with -error-status, from 0 to 1")
+	flags.IntVar(&mc.FailEvery, "fail-every", 0, "fail every n-th request with -error-status")
+	flags.IntVar(&mc.ErrorStatus, "error-status", http.StatusInternalServerError, "status of injected errors")
+
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	server, err := mock.NewFromFixtures(*fixtures, mc)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"addr":       *addr,
+		"latency":    mc.Latency,
+		"error_rate": mc.ErrorRate,
+		"fail_every": mc.FailEvery,
+	}).Info("Mock external API started")
+	return http.ListenAndServe(*addr, server.Handler())
+}
+
+func contractExternalCommand(args []string) error {
+	flags := flag.NewFlagSet("contract-external", flag.ContinueOnError)
+	link := flags.String("url", "", "people info API to check, the built-in mock serving -fixtures by default")
+	fixtures := flags.String("fixtures", "fixtures/people.json", "people expected to be found, *.json or *.csv file or a directory of them")
+	timeout := flags.Duration("timeout", 5*time.Second, "timeout of a request")
+
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	people, err := enrichment.NewFileProvider(*fixtures)
+	if err != nil {
+		return err
+	}
+
+	if *link == "" {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return err
+		}
+		defer listener.Close()
+
+		go http.Serve(listener, mock.New(people, mock.Config{}).Handler())
+		*link = "http://" + listener.Addr().String()
+	}
+
+	client := external.New(external.Config{BaseURL: *link, Timeout: *timeout})
+	results := enrichment.RunContract(context.Background(), client, enrichment.ContractCases(people))
+
+	var failed int
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CASE\tRESULT\tDETAILS")
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(w, "%s\tFAIL\t%s\n", r.Case.Name, r.Err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\tPASS\t\n", r.Case.Name)
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%s: %d of %d contract checks failed", *link, failed, len(results))
+	}
+	fmt.Printf("%s: %d contract checks passed\n", *link, len(results))
+	return nil
+}
@@ -4,20 +4,54 @@ import (
 	"github.com/joho/godotenv"
 	log "github.com/sirupsen/logrus"
 	"os"
+	"strconv"
+	"time"
 )
 
 type EnvFileConfig struct {
-	Host           string
-	Port           string
-	User           string
-	Password       string
-	Dbname         string
-	Sslmode        string
-	HTTPHost       string
-	HTTPPort       string
-	ExternalAPIURL string
-	AppLogLevel    string
-	DBLogLevel     string
+	Driver           string
+	Path             string
+	Host             string
+	Port             string
+	User             string
+	Password         string
+	Dbname           string
+	Sslmode          string
+	HTTPHost         string
+	HTTPPort         string
+	ShutdownTimeout  time.Duration
+	ReadTimeout      time.Duration
+	HeaderTimeout    time.Duration
+	WriteTimeout     time.Duration
+	IdleTimeout      time.Duration
+	MaxBodySize      int
+	TLSCertFile      string
+	TLSKeyFile       string
+	TLSReload        time.Duration
+	ExternalAPIURL   string
+	ExternalTimeout  time.Duration
+	ExternalRetries  int
+	ExternalBackoff  time.Duration
+	ExternalStrict   bool
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	Enrichment       string
+	EnrichmentDir    string
+	EnrichmentMode   string
+	EnrichWorkers    int
+	EnrichAttempts   int
+	EnrichBackoff    time.Duration
+	EnrichInterval   time.Duration
+	EnrichCache      string
+	EnrichCacheTTL   time.Duration
+	RefreshInterval  time.Duration
+	AppLogLevel      string
+	DBLogLevel       string
+	JWTSecret        string
+	JWTAccessTTL     time.Duration
+	JWTRefreshTTL    time.Duration
+	TrashRetention   time.Duration
+	TrashInterval    time.Duration
 }
 
 type Config struct {
@@ -30,17 +64,49 @@ func New() *Config {
 	}
 
 	return &Config{Config: EnvFileConfig{
-		Host:           getEnv("DB_HOST"),
-		Port:           getEnv("DB_PORT"),
-		User:           getEnv("DB_USER"),
-		Password:       getEnv("DB_PASSWORD"),
-		Dbname:         getEnv("DB_NAME"),
-		Sslmode:        getEnv("DB_SSLMODE"),
-		HTTPHost:       getEnv("HTTP_HOST"),
-		HTTPPort:       getEnv("HTTP_PORT"),
-		ExternalAPIURL: getEnv("EXTERNAL_API_URL"),
-		AppLogLevel:    getEnv("APP_LOG_LEVEL"),
-		DBLogLevel:     getEnv("DB_LOG_LEVEL"),
+		Driver:           getEnvDefault("DB_DRIVER", "postgres"),
+		Path:             getEnvDefault("DB_PATH", "time_tracker.db"),
+		Host:             getEnv("DB_HOST"),
+		Port:             getEnv("DB_PORT"),
+		User:             getEnv("DB_USER"),
+		Password:         getEnv("DB_PASSWORD"),
+		Dbname:           getEnv("DB_NAME"),
+		Sslmode:          getEnv("DB_SSLMODE"),
+		HTTPHost:         getEnv("HTTP_HOST"),
+		HTTPPort:         getEnv("HTTP_PORT"),
+		ShutdownTimeout:  getEnvDuration("HTTP_SHUTDOWN_TIMEOUT", 15*time.Second),
+		ReadTimeout:      getEnvDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+		HeaderTimeout:    getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:     getEnvDuration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:      getEnvDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		MaxBodySize:      getEnvInt("HTTP_MAX_BODY_SIZE", 1<<20),
+		TLSCertFile:      getEnv("TLS_CERT_FILE"),
+		TLSKeyFile:       getEnv("TLS_KEY_FILE"),
+		TLSReload:        getEnvDuration("TLS_RELOAD_INTERVAL", time.Minute),
+		ExternalAPIURL:   getEnv("EXTERNAL_API_URL"),
+		ExternalTimeout:  getEnvDuration("EXTERNAL_API_TIMEOUT", 5*time.Second),
+		ExternalRetries:  getEnvInt("EXTERNAL_API_RETRIES", 2),
+		ExternalBackoff:  getEnvDuration("EXTERNAL_API_BACKOFF", 200*time.Millisecond),
+		ExternalStrict:   getEnvBool("EXTERNAL_API_STRICT", false),
+		BreakerThreshold: getEnvInt("EXTERNAL_API_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:  getEnvDuration("EXTERNAL_API_BREAKER_COOLDOWN", 30*time.Second),
+		Enrichment:       getEnvDefault("ENRICHMENT_PROVIDER", "http"),
+		EnrichmentDir:    getEnv("ENRICHMENT_DIR"),
+		EnrichmentMode:   getEnvDefault("ENRICHMENT_MODE", "sync"),
+		EnrichWorkers:    getEnvInt("ENRICHMENT_WORKERS", 4),
+		EnrichAttempts:   getEnvInt("ENRICHMENT_ATTEMPTS", 5),
+		EnrichBackoff:    getEnvDuration("ENRICHMENT_BACKOFF", time.Second),
+		EnrichInterval:   getEnvDuration("ENRICHMENT_POLL_INTERVAL", 30*time.Second),
+		EnrichCache:      getEnvDefault("ENRICHMENT_CACHE", "memory"),
+		EnrichCacheTTL:   getEnvDuration("ENRICHMENT_CACHE_TTL", 24*time.Hour),
+		RefreshInterval:  getEnvDuration("USER_REFRESH_INTERVAL", 0),
+		AppLogLevel:      getEnv("APP_LOG_LEVEL"),
+		DBLogLevel:       getEnv("DB_LOG_LEVEL"),
+		JWTSecret:        getEnv("JWT_SECRET"),
+		JWTAccessTTL:     getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute),
+		JWTRefreshTTL:    getEnvDuration("JWT_REFRESH_TTL", 7*24*time.Hour),
+		TrashRetention:   getEnvDuration("TRASH_RETENTION", 0),
+		TrashInterval:    getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
 	}}
 }
 
@@ -50,3 +116,53 @@ func getEnv(key string) string {
 	}
 	return ""
 }
+
+func getEnvDefault(key, defaultValue string) string {
+	value := getEnv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := getEnv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.WithField(key, value).Warn("Invalid number, using default ", defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := getEnv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.WithField(key, value).Warn("Invalid boolean, using default ", defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := getEnv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.WithField(key, value).Warn("Invalid duration, using default ", defaultValue)
+		return defaultValue
+	}
+	return d
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strings"
+)
+
+// Names of unique indexes and foreign keys created by migrations,
+// repositories map their violations to API errors.
+const (
+	IndexOrganizationsId = "idx_organizations_organization_id"
+	IndexUsersUserId     = "idx_users_user_id"
+	IndexUsersLogin      = "idx_users_login"
+	IndexUsersPassport   = "idx_users_passport"
+	IndexTasksTaskId     = "idx_tasks_task_id"
+	IndexAPIKeysKeyId    = "idx_api_keys_key_id"
+	FKTasksOwner         = "fk_tasks_owner"
+)
+
+const (
+	ViolationUnique     = "unique"
+	ViolationForeignKey = "foreign_key"
+)
+
+// ConstraintError describes a violated unique index or foreign key.
+type ConstraintError struct {
+	Kind       string
+	Constraint string
+	Err        error
+}
+
+func (c *ConstraintError) Error() string {
+	return c.Kind + " constraint " + c.Constraint + " violated: " + c.Err.Error()
+}
+
+func (c *ConstraintError) Unwrap() error {
+	return c.Err
+}
+
+// SQLite reports columns instead of index names, unique indexes are
+// recognized by their column list.
+var sqliteUniqueColumns = map[string]string{
+	"organizations.organization_id": IndexOrganizationsId,
+	"users.user_id":                 IndexUsersUserId,
+	"users.login":                   IndexUsersLogin,
+	"users.organization_id, users.passport_serie, users.passport_number": IndexUsersPassport,
+	"tasks.task_id":   IndexTasksTaskId,
+	"api_keys.key_id": IndexAPIKeysKeyId,
+}
+
+// AsConstraintError recognizes constraint violations of both drivers.
+func AsConstraintError(err error) (*ConstraintError, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return &ConstraintError{ViolationUnique, pgErr.ConstraintName, err}, true
+		case "23503":
+			return &ConstraintError{ViolationForeignKey, pgErr.ConstraintName, err}, true
+		}
+		return nil, false
+	}
+
+	msg := err.Error()
+	if i := strings.Index(msg, "UNIQUE constraint failed: "); i >= 0 {
+		columns := msg[i+len("UNIQUE constraint failed: "):]
+		if j := strings.Index(columns, " ("); j >= 0 {
+			columns = columns[:j]
+		}
+		return &ConstraintError{ViolationUnique, sqliteUniqueColumns[columns], err}, true
+	}
+	if strings.Contains(msg, "FOREIGN KEY constraint failed") {
+		// SQLite doesn't name the foreign key, tasks have the only one
+		return &ConstraintError{ViolationForeignKey, FKTasksOwner, err}, true
+	}
+	return nil, false
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"github.com/glebarez/sqlite"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -9,17 +10,69 @@ import (
 	"time_tracker/config"
 )
 
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 func Connect(c *config.Config, level logger.LogLevel) *gorm.DB {
-	var err error
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		c.Config.Host, c.Config.Port, c.Config.User, c.Config.Password, c.Config.Dbname, c.Config.Sslmode)
+	dialector, err := Dialector(c)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	DB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	DB, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(level),
 	})
 
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	if c.Config.Driver == DriverSQLite {
+		// SQLite allows a single writer, one connection avoids "database is locked" errors
+		sqlDB, err := DB.DB()
+		if err != nil {
+			log.Fatal(err)
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	log.WithField("driver", c.Config.Driver).Info("DB connected")
 	return DB
 }
+
+// Close closes the connection pool, connections in use are closed once released.
+func Close(d *gorm.DB) error {
+	sqlDB, err := d.DB()
+	if err != nil {
+		return err
+	}
+
+	err = sqlDB.Close()
+	if err != nil {
+		return err
+	}
+	log.Info("DB connection closed")
+	return nil
+}
+
+// Dialector returns the gorm dialector for the configured DB_DRIVER.
+func Dialector(c *config.Config) (gorm.Dialector, error) {
+	switch c.Config.Driver {
+	case DriverPostgres, "":
+		dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			c.Config.Host, c.Config.Port, c.Config.User, c.Config.Password, c.Config.Dbname, c.Config.Sslmode)
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		return sqlite.Open(SQLiteDSN(c.Config.Path)), nil
+	default:
+		return nil, fmt.Errorf("unknown DB driver %q, use %s or %s", c.Config.Driver, DriverPostgres, DriverSQLite)
+	}
+}
+
+// SQLiteDSN enables foreign keys and waits for locks instead of failing at once.
+// Path ":memory:" opens an in-memory database.
+func SQLiteDSN(path string) string {
+	return path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"time"
+)
+
+// LegacyOrganizationName is the organization users of databases created
+// before organizations were introduced are moved to.
+const LegacyOrganizationName = "Default"
+
+// legacyUser is a user created before organizations, logins and roles, its
+// new columns are NULL or empty.
+type legacyUser struct {
+	ID     uint
+	UserId uuid.UUID
+}
+
+// withoutOrganization matches rows created before organizations.
+func withoutOrganization(tx *gorm.DB) *gorm.DB {
+	return tx.Where("organization_id IS NULL OR organization_id = ?", uuid.Nil)
+}
+
+// adoptLegacyUsers moves users without an organization to a new one named
+// LegacyOrganizationName, gives them logins legacy-<user id> and makes the
+// earliest of them its admin. Their tasks follow them. Adopted users have no
+// password until an operator sets it with the set-password command.
+func adoptLegacyUsers(tx *gorm.DB) error {
+	var users []legacyUser
+	err := tx.Table("users").Scopes(withoutOrganization).Order("id").Find(&users).Error
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	buf := make([]byte, 8)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return err
+	}
+
+	org := v1Organization{OrganizationId: uuid.New(), Name: LegacyOrganizationName, JoinCode: hex.EncodeToString(buf)}
+	err = tx.Create(&org).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Table("users").Scopes(withoutOrganization).
+		Updates(map[string]interface{}{"organization_id": org.OrganizationId, "updated_at": time.Now()}).Error
+	if err != nil {
+		return err
+	}
+
+	for _, usr := range users {
+		err = tx.Table("users").Where("id = ? AND (login IS NULL OR login = '')", usr.ID).
+			Update("login", "legacy-"+usr.UserId.String()).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Table("users").Where("organization_id = ? AND (role IS NULL OR role = '')", org.OrganizationId).
+		Update("role", "member").Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Table("tasks").Scopes(withoutOrganization).
+		Update("organization_id", tx.Table("users").Select("organization_id").Where("users.user_id = tasks.owner_id")).Error
+	if err != nil {
+		return err
+	}
+
+	logger := log.WithFields(log.Fields{"users": len(users), "organization_id": org.OrganizationId, "join_code": org.JoinCode})
+
+	// deleted users can't be admins, if all adopted users are deleted the
+	// organization is left without one
+	var admin legacyUser
+	result := tx.Table("users").Where("organization_id = ? AND deleted_at IS NULL", org.OrganizationId).
+		Order("id").Limit(1).Find(&admin)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		err = tx.Table("users").Where("id = ?", admin.ID).Update("role", "admin").Error
+		if err != nil {
+			return err
+		}
+		logger = logger.WithField("admin_id", admin.UserId)
+	}
+
+	logger.Warn("Legacy users adopted, they can't log in until set-password gives them passwords")
+	return nil
+}
+
+// checkTaskOwners refuses to add the task owner foreign key while tasks of
+// legacy databases reference missing users, those have to be fixed by hand.
+func checkTaskOwners(tx *gorm.DB) error {
+	var orphans int64
+	err := tx.Table("tasks").Where("owner_id NOT IN (?)", tx.Table("users").Select("user_id")).
+		Count(&orphans).Error
+	if err != nil {
+		return err
+	}
+
+	if orphans > 0 {
+		return fmt.Errorf("%d task(s) reference missing users, delete them or restore their owners before migrating", orphans)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package db_test
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+	"time_tracker/api/service"
+	"time_tracker/api/task"
+	"time_tracker/api/user"
+	"time_tracker/db"
+)
+
+// legacyUser and legacyTask are the tables of databases created before
+// organizations, logins and versioned migrations.
+type legacyUser struct {
+	gorm.Model
+	PassportSerie  int
+	PassportNumber int
+	Name           string
+	Surname        string
+	Patronymic     string
+	Address        string
+	UserId         uuid.UUID
+}
+
+func (l *legacyUser) TableName() string {
+	return "users"
+}
+
+type legacyTask struct {
+	gorm.Model
+	TaskId   uuid.UUID
+	OwnerId  uuid.UUID
+	Title    string
+	Content  string
+	StartAt  time.Time
+	FinishAt time.Time
+	Duration int64
+}
+
+func (l *legacyTask) TableName() string {
+	return "tasks"
+}
+
+func createLegacy(t *testing.T, DB *gorm.DB, rows ...interface{}) {
+	t.Helper()
+
+	err := DB.AutoMigrate(&legacyUser{}, &legacyTask{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		err = DB.Create(row).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAdoptLegacyUsers(t *testing.T) {
+	DB := connect(t)
+
+	first := legacyUser{PassportSerie: 1000, PassportNumber: 100000, Name: "Ivan", UserId: uuid.New()}
+	second := legacyUser{PassportSerie: 2000, PassportNumber: 200000, Name: "Petr", UserId: uuid.New()}
+	tsk := legacyTask{TaskId: uuid.New(), OwnerId: second.UserId, Title: "Report"}
+	createLegacy(t, DB, &first, &second, &tsk)
+
+	_, err := db.MigrateUp(DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := user.NewGormRepository(DB)
+	var org user.Organization
+	err = DB.Where("name = ?", db.LegacyOrganizationName).First(&org).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin, err := users.ReadByLogin("legacy-" + first.UserId.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	member, err := users.ReadByLogin("legacy-" + second.UserId.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if admin.OrganizationId != org.OrganizationId || member.OrganizationId != org.OrganizationId {
+		t.Fatalf("expected users of the organization %s, got %s and %s",
+			org.OrganizationId, admin.OrganizationId, member.OrganizationId)
+	}
+	if admin.Role != user.RoleAdmin || member.Role != user.RoleMember {
+		t.Fatalf("expected the earliest user admin, got %s and %s", admin.Role, member.Role)
+	}
+
+	adopted, err := task.NewGormRepository(DB).ReadOne(org.OrganizationId, tsk.TaskId)
+	if err != nil {
+		t.Fatal("task not moved to the organization: ", err)
+	}
+	if adopted.OwnerId != second.UserId {
+		t.Fatalf("expected the task of %s, got %s", second.UserId, adopted.OwnerId)
+	}
+
+	if admin.CheckPassword("") {
+		t.Fatal("adopted user can log in without a password")
+	}
+	err = user.SetPassword(users, admin.Login, "admin-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err = users.ReadByLogin(admin.Login)
+	if err != nil || !admin.CheckPassword("admin-password") {
+		t.Fatalf("password not set: %v", err)
+	}
+
+	err = user.SetPassword(users, "unknown", "admin-password")
+	if !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("expected unknown login, got %v", err)
+	}
+	err = user.SetPassword(users, admin.Login, "short")
+	if !errors.As(err, new(service.FieldErrors)) {
+		t.Fatalf("expected short password rejected, got %v", err)
+	}
+}
+
+func TestAdoptLegacyUsersOrphanTasks(t *testing.T) {
+	DB := connect(t)
+
+	owner := legacyUser{PassportSerie: 1000, PassportNumber: 100000, UserId: uuid.New()}
+	orphan := legacyTask{TaskId: uuid.New(), OwnerId: uuid.New(), Title: "Orphan"}
+	createLegacy(t, DB, &owner, &orphan)
+
+	_, err := db.MigrateUp(DB)
+	if err == nil {
+		t.Fatal("expected tasks of missing users rejected")
+	}
+
+	err = db.CheckSchema(DB)
+	if !errors.Is(err, db.ErrSchemaOutdated) {
+		t.Fatalf("expected outdated schema, got %v", err)
+	}
+}
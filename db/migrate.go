@@ -0,0 +1,168 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Migration is one versioned schema change. Up and Down run inside a
+// transaction together with the schema_migrations bookkeeping.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+var ErrSchemaOutdated = errors.New("database schema is outdated, run 'migrate up'")
+
+// ErrIrreversible is returned by Down of migrations that can't be rolled
+// back, rollbacks stop at them.
+var ErrIrreversible = errors.New("irreversible migration")
+
+func (s *SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrateUp applies all pending migrations in version order and returns them.
+func MigrateUp(d *gorm.DB) ([]Migration, error) {
+	applied, err := appliedVersions(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		err = d.Transaction(func(tx *gorm.DB) error {
+			err := m.Up(tx)
+			if err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d %s: %w", m.Version, m.Name, err)
+		}
+
+		log.WithField("version", m.Version).Info("Migration applied: ", m.Name)
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown rolls back the last steps applied migrations and returns them.
+func MigrateDown(d *gorm.DB, steps int) ([]Migration, error) {
+	applied, err := appliedVersions(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		err = d.Transaction(func(tx *gorm.DB) error {
+			err := m.Down(tx)
+			if err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("rollback %d %s: %w", m.Version, m.Name, err)
+		}
+
+		log.WithField("version", m.Version).Info("Migration rolled back: ", m.Name)
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Status lists all known migrations and whether they are applied.
+func Status(d *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedVersions(d)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+		if row, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = row.AppliedAt
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+// CheckSchema returns ErrSchemaOutdated if some migrations are not applied,
+// and an error if the database was migrated by a newer version of the app.
+func CheckSchema(d *gorm.DB) error {
+	if !d.Migrator().HasTable(&SchemaMigration{}) {
+		return ErrSchemaOutdated
+	}
+
+	applied, err := appliedVersions(d)
+	if err != nil {
+		return err
+	}
+
+	known := map[int]bool{}
+	for _, m := range migrations {
+		known[m.Version] = true
+		if _, ok := applied[m.Version]; !ok {
+			return fmt.Errorf("%w: migration %d %s is pending", ErrSchemaOutdated, m.Version, m.Name)
+		}
+	}
+
+	for version := range applied {
+		if !known[version] {
+			return fmt.Errorf("database schema version %d is unknown to this build", version)
+		}
+	}
+	return nil
+}
+
+func appliedVersions(d *gorm.DB) (map[int]SchemaMigration, error) {
+	err := d.AutoMigrate(&SchemaMigration{})
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []SchemaMigration
+	err = d.Order("version").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
@@ -0,0 +1,310 @@
+package db_test
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/service"
+	"time_tracker/api/task"
+	"time_tracker/api/user"
+	"time_tracker/config"
+	"time_tracker/db"
+)
+
+// connect opens a new SQLite database file the way DB_DRIVER=sqlite does.
+func connect(t *testing.T) *gorm.DB {
+	t.Helper()
+	log.SetOutput(io.Discard)
+
+	c := &config.Config{Config: config.EnvFileConfig{
+		Driver: db.DriverSQLite,
+		Path:   filepath.Join(t.TempDir(), "time_tracker.db"),
+	}}
+	DB := db.Connect(c, logger.Silent)
+	t.Cleanup(func() { db.Close(DB) })
+	return DB
+}
+
+func migrate(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	DB := connect(t)
+	_, err := db.MigrateUp(DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return DB
+}
+
+func appliedCount(t *testing.T, DB *gorm.DB) int {
+	t.Helper()
+
+	status, err := db.Status(DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var applied int
+	for _, s := range status {
+		if s.Applied {
+			applied++
+		}
+	}
+	return applied
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	DB := connect(t)
+
+	err := db.CheckSchema(DB)
+	if !errors.Is(err, db.ErrSchemaOutdated) {
+		t.Fatalf("expected outdated schema of an empty database, got %v", err)
+	}
+
+	status, err := db.Status(DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := len(status)
+
+	// adopt_legacy_users, version 2, can't be rolled back, later rounds
+	// apply the migrations above it
+	want := total
+	for round := 0; round < 2; round++ {
+		done, err := db.MigrateUp(DB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(done) != want || appliedCount(t, DB) != total {
+			t.Fatalf("expected %d migrations applied, got %d", want, len(done))
+		}
+
+		err = db.CheckSchema(DB)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		done, err = db.MigrateUp(DB)
+		if err != nil || len(done) != 0 {
+			t.Fatalf("expected nothing to apply, got %d, %v", len(done), err)
+		}
+
+		done, err = db.MigrateDown(DB, 1)
+		if err != nil || len(done) != 1 {
+			t.Fatalf("expected one migration rolled back, got %d, %v", len(done), err)
+		}
+		err = db.CheckSchema(DB)
+		if !errors.Is(err, db.ErrSchemaOutdated) {
+			t.Fatalf("expected outdated schema, got %v", err)
+		}
+
+		done, err = db.MigrateDown(DB, total)
+		if !errors.Is(err, db.ErrIrreversible) || len(done) != total-3 {
+			t.Fatalf("expected %d migrations rolled back before the irreversible one, got %d, %v", total-3, len(done), err)
+		}
+		if n := appliedCount(t, DB); n != 2 {
+			t.Fatalf("expected the first 2 migrations kept, got %d", n)
+		}
+		for _, table := range []string{"organizations", "users", "tasks", "api_keys"} {
+			if !DB.Migrator().HasTable(table) {
+				t.Fatalf("table %s dropped by a stopped rollback", table)
+			}
+		}
+		want = total - 2
+	}
+}
+
+func TestMigrateUnknownVersion(t *testing.T) {
+	DB := migrate(t)
+
+	err := DB.Create(&db.SchemaMigration{Version: 1000, Name: "from_the_future", AppliedAt: time.Now()}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.CheckSchema(DB)
+	if err == nil || errors.Is(err, db.ErrSchemaOutdated) {
+		t.Fatalf("expected unknown version error, got %v", err)
+	}
+}
+
+func TestUserRepositoryConstraints(t *testing.T) {
+	users := user.NewGormRepository(migrate(t))
+
+	org := user.Organization{OrganizationId: uuid.New(), Name: "Acme", JoinCode: "acme"}
+	other := user.Organization{OrganizationId: uuid.New(), Name: "Other", JoinCode: "other"}
+	for _, o := range []*user.Organization{&org, &other} {
+		err := users.CreateOrganization(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newUser := func(o user.Organization, login string, number int) *user.FullUser {
+		return &user.FullUser{UserId: uuid.New(), OrganizationId: o.OrganizationId, Login: login,
+			PassportSerie: 1000, PassportNumber: number}
+	}
+
+	first := newUser(org, "first", 100000)
+	err := users.Create(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		usr  *user.FullUser
+		err  error
+	}{
+		{"login taken", newUser(other, "first", 200000), service.ErrLoginExists},
+		{"passport taken in organization", newUser(org, "second", 100000), service.ErrPassportExists},
+		{"passport of another organization", newUser(other, "second", 100000), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := users.Create(tt.usr)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+
+	// logins of deleted users may be reused, restoring them is a conflict then
+	err = users.Delete(org.OrganizationId, first.UserId, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = users.Create(newUser(org, "first", 300000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = users.Restore(org.OrganizationId, first.UserId)
+	if !errors.Is(err, service.ErrLoginExists) {
+		t.Fatalf("expected login conflict on restore, got %v", err)
+	}
+}
+
+func TestTaskRepositoryConstraints(t *testing.T) {
+	DB := migrate(t)
+	users := user.NewGormRepository(DB)
+	tasks := task.NewGormRepository(DB)
+
+	org := user.Organization{OrganizationId: uuid.New(), Name: "Acme", JoinCode: "acme"}
+	err := users.CreateOrganization(&org)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := user.FullUser{UserId: uuid.New(), OrganizationId: org.OrganizationId, Login: "owner",
+		PassportSerie: 1000, PassportNumber: 100000}
+	err = users.Create(&owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orphan := task.FullTask{TaskId: uuid.New(), OwnerId: uuid.New(), OrganizationId: org.OrganizationId, Title: "Orphan"}
+	err = tasks.Create(&orphan)
+	if !errors.Is(err, service.ErrTaskOwnerNotFound) {
+		t.Fatalf("expected unknown owner, got %v", err)
+	}
+
+	tsk := task.FullTask{TaskId: uuid.New(), OwnerId: owner.UserId, OrganizationId: org.OrganizationId, Title: "Report"}
+	err = tasks.Create(&tsk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	duplicate := task.FullTask{TaskId: tsk.TaskId, OwnerId: owner.UserId, OrganizationId: org.OrganizationId, Title: "Copy"}
+	err = tasks.Create(&duplicate)
+	if !errors.Is(err, service.ErrConflict) {
+		t.Fatalf("expected duplicate task id conflict, got %v", err)
+	}
+
+	// the start is conditional, the second one doesn't overwrite the first
+	started := tsk
+	started.StartAt = time.Now().Add(-time.Hour)
+	err = tasks.Start(&started)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again := tsk
+	again.StartAt = time.Now()
+	err = tasks.Start(&again)
+	if err == nil {
+		t.Fatal("task started twice")
+	}
+
+	stored, err := tasks.ReadOne(org.OrganizationId, tsk.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.StartAt.Equal(started.StartAt) || stored.Version != 2 {
+		t.Fatalf("expected the first start with version 2, got %v with version %d", stored.StartAt, stored.Version)
+	}
+
+	// tasks keep their deleted owner until they are purged
+	err = users.Delete(org.OrganizationId, owner.UserId, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = users.Purge(org.OrganizationId, owner.UserId)
+	if !errors.Is(err, service.ErrUserHasTasks) {
+		t.Fatalf("expected the owner kept by the foreign key, got %v", err)
+	}
+
+	err = tasks.Purge(tsk.TaskId)
+	if !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("expected a live task not purged, got %v", err)
+	}
+	err = tasks.DeleteByOwner(owner.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tasks.PurgeByOwner(owner.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = users.Purge(org.OrganizationId, owner.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserRepositoryEnrichment(t *testing.T) {
+	users := user.NewGormRepository(migrate(t))
+
+	submitted := enrichment.Person{Name: "Ivan", Surname: "Ivanov", Address: "Moscow"}
+	usr := user.FullUser{UserId: uuid.New(), OrganizationId: uuid.New(), Login: "pending",
+		PassportSerie: 1000, PassportNumber: 100000, EnrichmentStatus: user.EnrichmentPending, SubmittedPerson: &submitted}
+	err := users.Create(&usr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := users.ReadPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].SubmittedPerson == nil || *pending[0].SubmittedPerson != submitted {
+		t.Fatalf("expected the pending user with the submitted person, got %+v", pending)
+	}
+
+	err = users.SetEnrichment(usr.OrganizationId, usr.UserId, user.EnrichmentResult{Status: user.EnrichmentComplete, Person: submitted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := users.ReadOne(usr.OrganizationId, usr.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.EnrichmentStatus != user.EnrichmentComplete || stored.Name != submitted.Name || stored.SubmittedPerson != nil {
+		t.Fatalf("expected the enriched user without the submitted person, got %+v", stored)
+	}
+}
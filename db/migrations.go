@@ -0,0 +1,357 @@
+package db
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// migrations are applied in slice order, versions must grow. Models are
+// copied into each migration so that later changes of api models don't
+// change what an already released migration does.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_initial_tables",
+		Up: func(tx *gorm.DB) error {
+			// AutoMigrate instead of CreateTable adopts databases created before
+			// versioned migrations were introduced
+			return tx.AutoMigrate(&v1Organization{}, &v1User{}, &v1Task{}, &v1APIKey{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v1APIKey{}, &v1Task{}, &v1User{}, &v1Organization{})
+		},
+	},
+	{
+		Version: 2,
+		Name:    "adopt_legacy_users",
+		Up:      adoptLegacyUsers,
+		Down: func(tx *gorm.DB) error {
+			// the organization adopted users were moved to isn't recorded
+			return ErrIrreversible
+		},
+	},
+	{
+		Version: 3,
+		Name:    "add_unique_indexes_and_task_owner_fk",
+		Up: func(tx *gorm.DB) error {
+			err := createIndexes(tx, &v3Organization{}, IndexOrganizationsId)
+			if err != nil {
+				return err
+			}
+
+			// passports and logins of soft-deleted users may be reused
+			err = createIndexes(tx, &v3User{}, IndexUsersUserId, IndexUsersLogin, IndexUsersPassport)
+			if err != nil {
+				return err
+			}
+
+			err = createIndexes(tx, &v3APIKey{}, IndexAPIKeysKeyId)
+			if err != nil {
+				return err
+			}
+
+			err = checkTaskOwners(tx)
+			if err != nil {
+				return err
+			}
+
+			err = tx.Migrator().CreateConstraint(&v3Task{}, "Owner")
+			if err != nil {
+				return err
+			}
+
+			// SQLite rebuilds the table to add a foreign key and loses its indexes,
+			// so the old ones are recreated as well
+			return createIndexes(tx, &v3Task{},
+				"idx_tasks_deleted_at", "idx_tasks_organization_id", IndexTasksTaskId, "idx_tasks_owner_id")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().DropConstraint(&v3Task{}, "Owner")
+			if err != nil {
+				return err
+			}
+
+			err = dropIndexes(tx, &v3Task{}, IndexTasksTaskId, "idx_tasks_owner_id")
+			if err != nil {
+				return err
+			}
+
+			err = createIndexes(tx, &v1Task{}, "idx_tasks_deleted_at", "idx_tasks_organization_id")
+			if err != nil {
+				return err
+			}
+
+			err = dropIndexes(tx, &v3APIKey{}, IndexAPIKeysKeyId)
+			if err != nil {
+				return err
+			}
+
+			err = dropIndexes(tx, &v3User{}, IndexUsersUserId, IndexUsersLogin, IndexUsersPassport)
+			if err != nil {
+				return err
+			}
+
+			return dropIndexes(tx, &v3Organization{}, IndexOrganizationsId)
+		},
+	},
+	{
+		Version: 4,
+		Name:    "add_version_columns",
+		Up: func(tx *gorm.DB) error {
+			// existing rows get version 1 from the column default
+			err := tx.Migrator().AddColumn(&v4User{}, "Version")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().AddColumn(&v4Task{}, "Version")
+		},
+		Down: func(tx *gorm.DB) error {
+			// plain ALTER TABLE, the SQLite migrator would rebuild the tables and lose their indexes
+			err := tx.Exec("ALTER TABLE tasks DROP COLUMN version").Error
+			if err != nil {
+				return err
+			}
+			return tx.Exec("ALTER TABLE users DROP COLUMN version").Error
+		},
+	},
+	{
+		Version: 5,
+		Name:    "add_user_enrichment_status",
+		Up: func(tx *gorm.DB) error {
+			// existing users were enriched on creation and get the complete status
+			err := tx.Migrator().AddColumn(&v5User{}, "EnrichmentStatus")
+			if err != nil {
+				return err
+			}
+			err = tx.Migrator().AddColumn(&v5User{}, "EnrichmentError")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().AddColumn(&v5User{}, "SubmittedPerson")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Exec("ALTER TABLE users DROP COLUMN submitted_person").Error
+			if err != nil {
+				return err
+			}
+			err = tx.Exec("ALTER TABLE users DROP COLUMN enrichment_error").Error
+			if err != nil {
+				return err
+			}
+			return tx.Exec("ALTER TABLE users DROP COLUMN enrichment_status").Error
+		},
+	},
+	{
+		Version: 6,
+		Name:    "create_enrichment_cache",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&v6EnrichmentCache{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v6EnrichmentCache{})
+		},
+	},
+}
+
+type v1Organization struct {
+	gorm.Model
+	OrganizationId uuid.UUID
+	Name           string
+	JoinCode       string `gorm:"uniqueIndex"`
+}
+
+func (v *v1Organization) TableName() string {
+	return "organizations"
+}
+
+type v1User struct {
+	gorm.Model
+	PassportSerie  int
+	PassportNumber int
+	Name           string
+	Surname        string
+	Patronymic     string
+	Address        string
+	UserId         uuid.UUID
+	Login          string
+	Role           string `gorm:"default:member"`
+	ManagerId      uuid.UUID
+	OrganizationId uuid.UUID `gorm:"index"`
+	PasswordHash   string
+}
+
+func (v *v1User) TableName() string {
+	return "users"
+}
+
+type v1Task struct {
+	gorm.Model
+	TaskId         uuid.UUID
+	OwnerId        uuid.UUID
+	Title          string
+	Content        string
+	StartAt        time.Time
+	FinishAt       time.Time
+	Duration       int64
+	OrganizationId uuid.UUID `gorm:"index"`
+}
+
+func (v *v1Task) TableName() string {
+	return "tasks"
+}
+
+type v1APIKey struct {
+	ID         uint `gorm:"primarykey"`
+	KeyId      uuid.UUID
+	UserId     uuid.UUID `gorm:"index"`
+	Name       string
+	Prefix     string
+	KeyHash    string `gorm:"uniqueIndex"`
+	Scopes     string
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+func (v *v1APIKey) TableName() string {
+	return "api_keys"
+}
+
+type v3Organization struct {
+	gorm.Model
+	OrganizationId uuid.UUID `gorm:"uniqueIndex:idx_organizations_organization_id"`
+	Name           string
+	JoinCode       string `gorm:"uniqueIndex"`
+}
+
+func (v *v3Organization) TableName() string {
+	return "organizations"
+}
+
+type v3User struct {
+	gorm.Model
+	PassportSerie  int `gorm:"uniqueIndex:idx_users_passport,priority:2"`
+	PassportNumber int `gorm:"uniqueIndex:idx_users_passport,priority:3"`
+	Name           string
+	Surname        string
+	Patronymic     string
+	Address        string
+	UserId         uuid.UUID `gorm:"uniqueIndex:idx_users_user_id"`
+	Login          string    `gorm:"uniqueIndex:idx_users_login,where:deleted_at IS NULL"`
+	Role           string    `gorm:"default:member"`
+	ManagerId      uuid.UUID
+	OrganizationId uuid.UUID `gorm:"index;uniqueIndex:idx_users_passport,priority:1,where:deleted_at IS NULL"`
+	PasswordHash   string
+}
+
+func (v *v3User) TableName() string {
+	return "users"
+}
+
+type v3Task struct {
+	gorm.Model
+	TaskId         uuid.UUID `gorm:"uniqueIndex:idx_tasks_task_id"`
+	OwnerId        uuid.UUID `gorm:"index"`
+	Owner          v3User    `gorm:"foreignKey:OwnerId;references:UserId;constraint:fk_tasks_owner,OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Title          string
+	Content        string
+	StartAt        time.Time
+	FinishAt       time.Time
+	Duration       int64
+	OrganizationId uuid.UUID `gorm:"index"`
+}
+
+func (v *v3Task) TableName() string {
+	return "tasks"
+}
+
+type v3APIKey struct {
+	ID         uint      `gorm:"primarykey"`
+	KeyId      uuid.UUID `gorm:"uniqueIndex:idx_api_keys_key_id"`
+	UserId     uuid.UUID `gorm:"index"`
+	Name       string
+	Prefix     string
+	KeyHash    string `gorm:"uniqueIndex"`
+	Scopes     string
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+func (v *v3APIKey) TableName() string {
+	return "api_keys"
+}
+
+type v4User struct {
+	Version int64 `gorm:"not null;default:1"`
+}
+
+func (v *v4User) TableName() string {
+	return "users"
+}
+
+type v4Task struct {
+	Version int64 `gorm:"not null;default:1"`
+}
+
+func (v *v4Task) TableName() string {
+	return "tasks"
+}
+
+type v5User struct {
+	EnrichmentStatus string `gorm:"not null;default:complete"`
+	EnrichmentError  string
+	// SubmittedPerson holds JSON of the personal data sent on creation
+	SubmittedPerson string
+}
+
+func (v *v5User) TableName() string {
+	return "users"
+}
+
+type v6EnrichmentCache struct {
+	ID             uint `gorm:"primarykey"`
+	PassportSerie  int  `gorm:"uniqueIndex:idx_enrichment_cache_passport"`
+	PassportNumber int  `gorm:"uniqueIndex:idx_enrichment_cache_passport"`
+	Name           string
+	Patronymic     string
+	Surname        string
+	Address        string
+	ExpiresAt      time.Time `gorm:"index"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (v *v6EnrichmentCache) TableName() string {
+	return "enrichment_cache"
+}
+
+// createIndexes creates indexes declared in model tags unless they exist.
+func createIndexes(tx *gorm.DB, model interface{}, names ...string) error {
+	for _, name := range names {
+		if tx.Migrator().HasIndex(model, name) {
+			continue
+		}
+		err := tx.Migrator().CreateIndex(model, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dropIndexes(tx *gorm.DB, model interface{}, names ...string) error {
+	for _, name := range names {
+		if !tx.Migrator().HasIndex(model, name) {
+			continue
+		}
+		err := tx.Migrator().DropIndex(model, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,13 +1,30 @@
 package main
 
 import (
+	"context"
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	"time_tracker/api/auth"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/external"
+	"time_tracker/api/health"
 	"time_tracker/api/task"
+	"time_tracker/api/trash"
 	"time_tracker/api/user"
 	"time_tracker/config"
 	"time_tracker/db"
 )
 
+// The docs package is generated from the annotations below and in handlers,
+// run go generate after changing them.
+//
+//go:generate swag init
+
 // @title			Time Tracker
 // @version		1.0
 // @contact.name	Telegram
@@ -17,6 +34,11 @@ import (
 //
 // @host			localhost:9000
 // @BasePath		/api/v1
+//
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				Access token in format 'Bearer <token>'
 func main() {
 	c := config.New()
 
@@ -26,15 +48,159 @@ func main() {
 		TimestampFormat: "02-01-2006 15:04:05",
 	})
 
-	user.ExternalAPIURL = c.Config.ExternalAPIURL
+	if len(os.Args) > 1 {
+		err := runCommand(c, os.Args[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	DB := db.Connect(c, DBSetLogLevel(c.Config.DBLogLevel))
-	user.Init(DB)
-	task.Init(DB)
+	err := db.CheckSchema(DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	keys := auth.Init(DB, c.Config.JWTSecret, c.Config.JWTAccessTTL, c.Config.JWTRefreshTTL)
+	users := user.Init(DB)
+	tasks := task.Init(DB)
+	store := task.NewGormStore(DB)
 
-	server := NewApiServer(c.Config.HTTPHost, c.Config.HTTPPort)
-	err := server.Run()
+	enricher, err := newEnrichment(c, DB)
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	// workers get their own context, they are stopped after requests which
+	// may enqueue work are drained
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	run := func(fn func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			fn(workersCtx)
+		}()
+	}
+
+	var queue *user.EnrichmentQueue
+	if c.Config.EnrichmentMode == "async" {
+		queue = user.NewEnrichmentQueue(users, enricher.Provider,
+			c.Config.EnrichWorkers, c.Config.EnrichAttempts, c.Config.EnrichBackoff, c.Config.EnrichInterval)
+		run(queue.Run)
+	}
+
+	if c.Config.TrashRetention > 0 {
+		run(trash.NewRetention(users, tasks, store, c.Config.TrashRetention, c.Config.TrashInterval).Run)
+	}
+
+	if c.Config.RefreshInterval > 0 {
+		run(user.NewRefresher(users, enricher.Provider, c.Config.RefreshInterval).Run)
+	}
+
+	server := NewApiServer(ServerConfig{
+		Addr:              c.Config.HTTPHost + ":" + c.Config.HTTPPort,
+		ReadTimeout:       c.Config.ReadTimeout,
+		ReadHeaderTimeout: c.Config.HeaderTimeout,
+		WriteTimeout:      c.Config.WriteTimeout,
+		IdleTimeout:       c.Config.IdleTimeout,
+		ShutdownTimeout:   c.Config.ShutdownTimeout,
+		MaxBodySize:       int64(c.Config.MaxBodySize),
+		CertFile:          c.Config.TLSCertFile,
+		KeyFile:           c.Config.TLSKeyFile,
+		CertReload:        c.Config.TLSReload,
+	},
+		auth.NewHandler(keys, users),
+		user.NewHandler(users, store, keys, enricher.Provider, queue),
+		task.NewHandler(tasks, users),
+		trash.NewHandler(users, tasks, store),
+		health.NewHandler(DB, users, enricher.External, enricher.Cache),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		// a second signal kills the process at once
+		stop()
+	}()
+
+	serverErr := server.Run(ctx)
+
+	stopWorkers()
+	if !waitTimeout(&workers, c.Config.ShutdownTimeout) {
+		log.Warn("Background workers didn't stop in time")
+	}
+
+	err = db.Close(DB)
+	if err != nil {
+		log.Error(err)
+	}
+
+	if serverErr != nil {
+		log.Fatal(serverErr)
+	}
+	log.Info("Shutdown complete")
+}
+
+// waitTimeout waits for wg, false means timeout passed first.
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// enrichmentDeps is the configured enrichment provider, External and Cache
+// are its parts reported by the health check, nil if they aren't used.
+type enrichmentDeps struct {
+	Provider enrichment.Provider
+	External *external.Client
+	Cache    *enrichment.CachingProvider
+}
+
+func newEnrichment(c *config.Config, DB *gorm.DB) (enrichmentDeps, error) {
+	var deps enrichmentDeps
+	if c.Config.Enrichment == enrichment.ProviderHTTP {
+		deps.External = external.New(external.Config{
+			BaseURL:          c.Config.ExternalAPIURL,
+			Timeout:          c.Config.ExternalTimeout,
+			Retries:          c.Config.ExternalRetries,
+			Backoff:          c.Config.ExternalBackoff,
+			BreakerThreshold: c.Config.BreakerThreshold,
+			BreakerCooldown:  c.Config.BreakerCooldown,
+		})
+	}
+
+	provider, err := enrichment.New(enrichment.Config{
+		Provider: c.Config.Enrichment,
+		Dir:      c.Config.EnrichmentDir,
+		Client:   deps.External,
+		Strict:   c.Config.ExternalStrict,
+	})
+	if err != nil {
+		return enrichmentDeps{}, err
+	}
+	deps.Provider = provider
+
+	// other providers don't make requests worth caching
+	if c.Config.Enrichment == enrichment.ProviderHTTP {
+		cache, err := enrichment.NewCache(c.Config.EnrichCache, DB, c.Config.EnrichCacheTTL)
+		if err != nil {
+			return enrichmentDeps{}, err
+		}
+		if cache != nil {
+			deps.Cache = enrichment.NewCachingProvider(provider, cache)
+			deps.Provider = deps.Cache
+		}
+	}
+	return deps, nil
 }
@@ -1,26 +1,123 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"net/http"
+	"time"
+	"time_tracker/api/auth"
+	"time_tracker/api/health"
+	"time_tracker/api/service"
 	"time_tracker/api/task"
+	"time_tracker/api/trash"
 	"time_tracker/api/user"
 	_ "time_tracker/docs"
 )
 
+// ServerConfig of the HTTP server. Zero timeouts disable them, zero
+// MaxBodySize doesn't limit request bodies. TLS is served when CertFile and
+// KeyFile are set, the files are reread every CertReload if they change.
+type ServerConfig struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	MaxBodySize       int64
+	CertFile          string
+	KeyFile           string
+	CertReload        time.Duration
+}
+
+// ApiServer serves the API until its context is cancelled. Requests in
+// flight are then given ShutdownTimeout to finish.
 type ApiServer struct {
-	Addr string
+	Config ServerConfig
+	Auth   *auth.Handler
+	Users  *user.Handler
+	Tasks  *task.Handler
+	Trash  *trash.Handler
+	Health *health.Handler
+}
+
+func NewApiServer(c ServerConfig, authHandler *auth.Handler, userHandler *user.Handler, taskHandler *task.Handler, trashHandler *trash.Handler, healthHandler *health.Handler) *ApiServer {
+	return &ApiServer{
+		Config: c,
+		Auth:   authHandler,
+		Users:  userHandler,
+		Tasks:  taskHandler,
+		Trash:  trashHandler,
+		Health: healthHandler,
+	}
 }
 
-func NewApiServer(host, port string) *ApiServer {
-	return &ApiServer{Addr: host + ":" + port}
+// Run blocks until ctx is cancelled and the requests are drained or the
+// server fails. Connections still active after ShutdownTimeout are closed.
+func (a *ApiServer) Run(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              a.Config.Addr,
+		Handler:           a.Handler(),
+		ReadTimeout:       a.Config.ReadTimeout,
+		ReadHeaderTimeout: a.Config.ReadHeaderTimeout,
+		WriteTimeout:      a.Config.WriteTimeout,
+		IdleTimeout:       a.Config.IdleTimeout,
+	}
+
+	tlsEnabled := a.Config.CertFile != "" || a.Config.KeyFile != ""
+	if tlsEnabled {
+		certs, err := newCertReloader(a.Config.CertFile, a.Config.KeyFile)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+
+		if a.Config.CertReload > 0 {
+			watchCtx, stopWatch := context.WithCancel(ctx)
+			defer stopWatch()
+			go certs.Watch(watchCtx, a.Config.CertReload)
+		}
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.WithField("tls", tlsEnabled).Info("Starting server on ", a.Config.Addr)
+		if tlsEnabled {
+			// certificates come from TLSConfig.GetCertificate
+			errs <- server.ListenAndServeTLS("", "")
+			return
+		}
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.WithField("timeout", a.Config.ShutdownTimeout).Info("Shutting down server, draining requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.Config.ShutdownTimeout)
+	defer cancel()
+
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		server.Close()
+		return fmt.Errorf("requests not drained: %w", err)
+	}
+	log.Info("Server stopped")
+	return nil
 }
 
-func (a *ApiServer) Run() error {
+// Handler routes requests to the API handlers through the middlewares.
+func (a *ApiServer) Handler() http.Handler {
 	router := http.NewServeMux()
-	server := &http.Server{Addr: a.Addr, Handler: corsMiddleware(router)}
 
 	router.HandleFunc("GET /api/v1", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, "API is ready.")
@@ -28,18 +125,21 @@ func (a *ApiServer) Run() error {
 
 	router.HandleFunc("GET /docs/", httpSwagger.WrapHandler)
 
-	user.AddRoutes(router)
-	task.AddRoutes(router)
+	a.Auth.AddRoutes(router)
+	a.Users.AddRoutes(router)
+	a.Tasks.AddRoutes(router)
+	a.Trash.AddRoutes(router)
+	a.Health.AddRoutes(router)
 
-	log.Info("Starting server on ", a.Addr)
-	return server.ListenAndServe()
+	return corsMiddleware(bodyLimitMiddleware(a.Config.MaxBodySize, a.Auth.Middleware(router)))
 }
 
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
@@ -47,3 +147,25 @@ func corsMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// bodyLimitMiddleware rejects requests declaring a body larger than limit
+// with 413 and cuts longer bodies, handlers report the cut ones as 413 too.
+func bodyLimitMiddleware(limit int64, next http.Handler) http.Handler {
+	if limit <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			var e service.ErrorResponse
+			e.ReadBodyError(&http.MaxBytesError{Limit: limit})
+			service.ServerResponse(w, e)
+			log.WithFields(log.Fields{"length": r.ContentLength, "limit": limit}).
+				Warn(r.Method, " ", r.URL.Path, ": request body is too large")
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"time_tracker/api/apitest"
+	"time_tracker/api/auth"
+	"time_tracker/api/enrichment"
+	"time_tracker/api/health"
+	"time_tracker/api/service"
+	"time_tracker/api/task"
+	"time_tracker/api/trash"
+	"time_tracker/api/user"
+)
+
+const testMaxBodySize = 4096
+
+// testAPI is the API server on a migrated in-memory SQLite database with an
+// organization, its admin and a member.
+type testAPI struct {
+	t        *testing.T
+	url      string
+	db       *gorm.DB
+	joinCode string
+	admin    string
+	member   string
+	adminId  uuid.UUID
+	memberId uuid.UUID
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	apitest.Setup()
+
+	DB := apitest.NewDB(t)
+	keys := auth.Init(DB, apitest.Secret, time.Minute, time.Hour)
+	users := user.Init(DB)
+	tasks := task.Init(DB)
+	store := task.NewGormStore(DB)
+	enricher := enrichment.NoopProvider{}
+	org, admin := apitest.CreateOrganization(t, store, enricher)
+
+	server := NewApiServer(ServerConfig{MaxBodySize: testMaxBodySize},
+		auth.NewHandler(keys, users),
+		user.NewHandler(users, store, keys, enricher, nil),
+		task.NewHandler(tasks, users),
+		trash.NewHandler(users, tasks, store),
+		health.NewHandler(DB, users, nil, nil),
+	)
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+
+	api := &testAPI{t: t, url: ts.URL, db: DB, joinCode: org.JoinCode, adminId: admin.UserId}
+	api.admin = api.login(apitest.Admin.Login, apitest.Admin.Password)
+
+	resp := api.do("POST", "/user", "", apitest.NewMember("member", "2000 200000", org.JoinCode))
+	resp.expect(http.StatusCreated, "")
+	api.memberId = resp.dataUUID()
+	api.member = api.login("member", apitest.MemberPassword)
+	return api
+}
+
+type testResponse struct {
+	t      *testing.T
+	req    string
+	status int
+	header http.Header
+	body   []byte
+}
+
+// do sends body, if not nil, as JSON to the API path with the access token.
+func (a *testAPI) do(method, path, token string, body interface{}, headers ...string) *testResponse {
+	a.t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			a.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, a.url+"/api/v1"+path, reader)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return &testResponse{t: a.t, req: method + " " + path, status: resp.StatusCode, header: resp.Header, body: data}
+}
+
+func (a *testAPI) login(login, password string) string {
+	a.t.Helper()
+
+	resp := a.do("POST", "/auth/login", "", user.Credentials{Login: login, Password: password})
+	resp.expect(http.StatusOK, "")
+
+	var ok struct {
+		Data auth.TokenPair `json:"data"`
+	}
+	resp.decode(&ok)
+	return ok.Data.AccessToken
+}
+
+// expect checks the status, code is the problem code expected in error responses.
+func (r *testResponse) expect(status int, code string) *testResponse {
+	r.t.Helper()
+
+	if r.status != status {
+		r.t.Fatalf("%s: expected status %d, got %d: %s", r.req, status, r.status, r.body)
+	}
+
+	if status >= 400 {
+		if ct := r.header.Get("Content-Type"); ct != "application/problem+json" {
+			r.t.Fatalf("%s: expected problem+json, got %q", r.req, ct)
+		}
+		var problem service.ErrorResponse
+		r.decode(&problem)
+		if problem.Status != status || problem.Code != code {
+			r.t.Fatalf("%s: expected problem %d %s, got %d %s", r.req, status, code, problem.Status, problem.Code)
+		}
+	}
+	return r
+}
+
+// expectNoContent checks for 204 without a body.
+func (r *testResponse) expectNoContent() {
+	r.t.Helper()
+
+	r.expect(http.StatusNoContent, "")
+	if len(r.body) != 0 {
+		r.t.Fatalf("%s: expected empty body, got %q", r.req, r.body)
+	}
+}
+
+func (r *testResponse) decode(v interface{}) {
+	r.t.Helper()
+
+	err := json.Unmarshal(r.body, v)
+	if err != nil {
+		r.t.Fatalf("%s: %v: %s", r.req, err, r.body)
+	}
+}
+
+// dataUUID returns data of OkResponse holding a UUID.
+func (r *testResponse) dataUUID() uuid.UUID {
+	r.t.Helper()
+
+	var ok struct {
+		Data uuid.UUID `json:"data"`
+	}
+	r.decode(&ok)
+	return ok.Data
+}
+
+func TestPublicRoutes(t *testing.T) {
+	api := newTestAPI(t)
+
+	api.do("GET", "", "", nil).expect(http.StatusOK, "")
+	var health struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	api.do("GET", "/health", "", nil).expect(http.StatusOK, "").decode(&health)
+	if len(health.Data) != 1 || health.Data["status"] != "ok" {
+		t.Fatalf("expected only the status in the public health check, got %v", health.Data)
+	}
+	api.do("GET", "/health/details", "", nil).expect(http.StatusUnauthorized, "auth.unauthorized")
+	api.do("GET", "/health/details", api.member, nil).expect(http.StatusForbidden, "auth.forbidden")
+	api.do("GET", "/health/details", api.admin, nil).expect(http.StatusOK, "")
+
+	api.do("POST", "/user", "", "{").expect(http.StatusBadRequest, "request.malformed_json")
+	api.do("POST", "/user", "", map[string]string{"passportNumber": "12"}).
+		expect(http.StatusBadRequest, "validation.failed")
+	api.do("POST", "/user", "", apitest.NewMember("another", "2000 200000", api.joinCode)).
+		expect(http.StatusConflict, "user.passport_exists")
+	api.do("POST", "/user", "", strings.Repeat(" ", testMaxBodySize+1)).
+		expect(http.StatusRequestEntityTooLarge, "request.body_too_large")
+
+	api.do("POST", "/auth/login", "", user.Credentials{Login: apitest.Admin.Login, Password: "wrong-password"}).
+		expect(http.StatusUnauthorized, "auth.unauthorized")
+
+	resp := api.do("POST", "/auth/login", "", user.Credentials{Login: "member", Password: apitest.MemberPassword})
+	var ok struct {
+		Data auth.TokenPair `json:"data"`
+	}
+	resp.expect(http.StatusOK, "").decode(&ok)
+	api.do("POST", "/auth/refresh", "", user.RefreshRequest{RefreshToken: ok.Data.RefreshToken}).
+		expect(http.StatusOK, "")
+	api.do("POST", "/auth/refresh", "", user.RefreshRequest{RefreshToken: ok.Data.AccessToken}).
+		expect(http.StatusUnauthorized, "auth.unauthorized")
+
+	api.do("POST", "/organization", "", map[string]string{"name": "Evil"}).
+		expect(http.StatusUnauthorized, "auth.unauthorized")
+}
+
+func TestUserRoutes(t *testing.T) {
+	api := newTestAPI(t)
+	member := fmt.Sprintf("/user/%s", api.memberId)
+
+	api.do("GET", member, "", nil).expect(http.StatusUnauthorized, "auth.unauthorized")
+	api.do("GET", "/user/not-a-uuid", api.admin, nil).expect(http.StatusBadRequest, "request.invalid_id")
+	api.do("GET", "/user/"+uuid.NewString(), api.admin, nil).expect(http.StatusNotFound, "not_found")
+	api.do("GET", fmt.Sprintf("/user/%s", api.adminId), api.member, nil).expect(http.StatusForbidden, "auth.forbidden")
+
+	resp := api.do("GET", member, api.admin, nil).expect(http.StatusOK, "")
+	etag := resp.header.Get("ETag")
+	api.do("GET", "/user", api.admin, nil).expect(http.StatusOK, "")
+	api.do("GET", "/organization", api.member, nil).expect(http.StatusOK, "")
+
+	api.do("PUT", member, api.member, map[string]string{"role": "admin"}).expect(http.StatusForbidden, "auth.forbidden")
+	api.do("PUT", member, api.admin, map[string]string{"address": "Omsk"}).expect(http.StatusOK, "")
+	api.do("PUT", member, api.admin, map[string]string{"address": "Tomsk"}, "If-Match", etag).
+		expect(http.StatusPreconditionFailed, "precondition_failed")
+	api.do("PUT", fmt.Sprintf("/user/%s", api.adminId), api.admin, map[string]string{"role": "member"}).
+		expect(http.StatusConflict, "user.last_admin")
+
+	api.do("POST", member+"/refresh?dryRun=true", api.member, nil).expect(http.StatusOK, "")
+	api.do("POST", member+"/refresh?dryRun=maybe", api.member, nil).expect(http.StatusBadRequest, "validation.failed")
+
+	api.do("DELETE", member, api.member, nil).expect(http.StatusForbidden, "auth.forbidden")
+	api.do("DELETE", fmt.Sprintf("/user/%s", api.adminId), api.admin, nil).expect(http.StatusConflict, "user.last_admin")
+	api.do("DELETE", member, api.admin, nil).expectNoContent()
+	api.do("GET", member, api.admin, nil).expect(http.StatusNotFound, "not_found")
+
+	api.do("GET", "/trash/users", api.admin, nil).expect(http.StatusOK, "")
+	api.do("GET", "/trash/users", api.member, nil).expect(http.StatusUnauthorized, "auth.unauthorized")
+	api.do("POST", strings.Replace(member, "/user/", "/trash/users/", 1)+"/restore", api.admin, nil).
+		expect(http.StatusOK, "")
+	api.do("DELETE", member, api.admin, nil).expectNoContent()
+	api.do("DELETE", strings.Replace(member, "/user/", "/trash/users/", 1), api.admin, nil).expectNoContent()
+}
+
+func TestAPIKeyRoutes(t *testing.T) {
+	api := newTestAPI(t)
+
+	api.do("POST", "/auth/keys", api.member, map[string]interface{}{"name": "CI", "scopes": []string{"bogus"}}).
+		expect(http.StatusBadRequest, "validation.failed")
+
+	resp := api.do("POST", "/auth/keys", api.member, map[string]interface{}{"name": "CI", "scopes": []string{"tasks:read"}})
+	var ok struct {
+		Data auth.CreatedAPIKey `json:"data"`
+	}
+	resp.expect(http.StatusCreated, "").decode(&ok)
+
+	api.do("GET", "/auth/keys", api.member, nil).expect(http.StatusOK, "")
+	api.do("GET", "/auth/keys", ok.Data.Key, nil).expect(http.StatusForbidden, "auth.forbidden")
+	api.do("POST", "/task", ok.Data.Key, task.CreateTask{Title: "Scoped"}).expect(http.StatusForbidden, "auth.forbidden")
+
+	api.do("DELETE", fmt.Sprintf("/auth/keys/%s", ok.Data.APIKey.KeyId), api.member, nil).expectNoContent()
+	api.do("DELETE", fmt.Sprintf("/auth/keys/%s", ok.Data.APIKey.KeyId), api.member, nil).expect(http.StatusNotFound, "not_found")
+	api.do("GET", fmt.Sprintf("/tasks/%s", api.memberId), ok.Data.Key, nil).expect(http.StatusUnauthorized, "auth.unauthorized")
+
+	// keys and tokens of deleted users stop working
+	resp = api.do("POST", "/auth/keys", api.member, map[string]interface{}{"name": "CI"})
+	resp.expect(http.StatusCreated, "").decode(&ok)
+	api.do("DELETE", fmt.Sprintf("/user/%s", api.memberId), api.admin, nil).expectNoContent()
+	api.do("GET", fmt.Sprintf("/tasks/%s", api.memberId), ok.Data.Key, nil).expect(http.StatusUnauthorized, "auth.unauthorized")
+	api.do("GET", "/auth/keys", api.member, nil).expect(http.StatusUnauthorized, "auth.unauthorized")
+	api.do("POST", "/auth/keys", api.member, map[string]interface{}{"name": "CI"}).
+		expect(http.StatusUnauthorized, "auth.unauthorized")
+}
+
+func TestTaskRoutes(t *testing.T) {
+	api := newTestAPI(t)
+
+	api.do("POST", "/task", api.member, "{").expect(http.StatusBadRequest, "request.malformed_json")
+	api.do("POST", "/task", api.member, task.CreateTask{}).expect(http.StatusBadRequest, "validation.failed")
+	api.do("POST", "/task", api.member, task.CreateTask{Title: strings.Repeat("x", testMaxBodySize)}).
+		expect(http.StatusRequestEntityTooLarge, "request.body_too_large")
+	exact := `{"title":"Exact"}`
+	api.do("POST", "/task", api.member, exact+strings.Repeat(" ", testMaxBodySize-len(exact))).
+		expect(http.StatusCreated, "")
+
+	taskId := api.do("POST", "/task", api.member, task.CreateTask{Title: "Report"}).
+		expect(http.StatusCreated, "").dataUUID()
+	path := fmt.Sprintf("/task/%s", taskId)
+
+	resp := api.do("GET", path, api.member, nil).expect(http.StatusOK, "")
+	etag := resp.header.Get("ETag")
+	api.do("GET", "/task/"+uuid.NewString(), api.member, nil).expect(http.StatusNotFound, "not_found")
+
+	api.do("GET", fmt.Sprintf("/task/finish/%s", taskId), api.member, nil).expect(http.StatusConflict, "task.not_started")
+	api.do("GET", fmt.Sprintf("/task/start/%s", taskId), api.member, nil).expect(http.StatusOK, "")
+	api.do("GET", fmt.Sprintf("/task/start/%s", taskId), api.member, nil).expect(http.StatusConflict, "task.already_started")
+	api.do("GET", fmt.Sprintf("/task/finish/%s", taskId), api.member, nil).expect(http.StatusOK, "")
+	api.do("GET", fmt.Sprintf("/task/finish/%s", taskId), api.member, nil).expect(http.StatusConflict, "task.already_finished")
+
+	api.do("PUT", path, api.member, task.UpdateTask{Title: "Stale"}, "If-Match", etag).
+		expect(http.StatusPreconditionFailed, "precondition_failed")
+	api.do("PUT", path, api.member, task.UpdateTask{Title: "Report", Content: "Quarterly"}).expect(http.StatusOK, "")
+
+	api.do("GET", fmt.Sprintf("/tasks/%s", api.memberId), api.member, nil).expect(http.StatusOK, "")
+	api.do("GET", fmt.Sprintf("/tasks/%s", api.adminId), api.member, nil).expect(http.StatusForbidden, "auth.forbidden")
+	api.do("GET", fmt.Sprintf("/tasks/summary/%s", api.memberId), api.member, nil).expect(http.StatusOK, "")
+	api.do("GET", fmt.Sprintf("/tasks/summary/%s", uuid.New()), api.member, nil).expect(http.StatusNotFound, "task.owner_not_found")
+
+	api.do("POST", "/tasks/batch", api.member, task.BatchRequest{Mode: task.BatchModeBestEffort,
+		Operations: []task.BatchOperation{{Op: "create", Title: "Batch"}, {Op: "start", TaskId: taskId}},
+	}).expect(http.StatusOK, "")
+	api.do("POST", "/tasks/batch", api.member, task.BatchRequest{}).expect(http.StatusBadRequest, "validation.failed")
+
+	api.do("DELETE", path, api.admin, nil).expectNoContent()
+	api.do("DELETE", path, api.member, nil).expect(http.StatusNotFound, "not_found")
+
+	api.do("GET", "/trash/tasks", api.admin, nil).expect(http.StatusOK, "")
+	api.do("POST", fmt.Sprintf("/trash/tasks/%s/restore", taskId), api.admin, nil).expect(http.StatusOK, "")
+	api.do("DELETE", fmt.Sprintf("/trash/tasks/%s", taskId), api.admin, nil).expect(http.StatusNotFound, "not_found")
+	api.do("DELETE", path, api.member, nil).expectNoContent()
+	api.do("DELETE", fmt.Sprintf("/trash/tasks/%s", taskId), api.admin, nil).expectNoContent()
+
+	taskId = api.do("POST", "/task", api.member, task.CreateTask{Title: "Cascaded"}).
+		expect(http.StatusCreated, "").dataUUID()
+	api.do("DELETE", fmt.Sprintf("/user/%s", api.memberId), api.admin, nil).expect(http.StatusConflict, "user.has_tasks")
+	api.do("DELETE", fmt.Sprintf("/user/%s?tasks=cascade", api.memberId), api.admin, nil).expectNoContent()
+
+	restore := fmt.Sprintf("/trash/users/%s/restore", api.memberId)
+	api.do("POST", restore+"?tasks=bogus", api.admin, nil).expect(http.StatusBadRequest, "validation.failed")
+	api.do("POST", restore+"?tasks=restore", api.admin, nil).expect(http.StatusOK, "")
+	api.do("GET", fmt.Sprintf("/task/%s", taskId), api.admin, nil).expect(http.StatusOK, "")
+}
+
+func TestDatabaseErrors(t *testing.T) {
+	api := newTestAPI(t)
+
+	err := api.db.Migrator().DropTable("tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api.do("GET", fmt.Sprintf("/tasks/%s", api.memberId), api.member, nil).expect(http.StatusInternalServerError, "database")
+	api.do("GET", fmt.Sprintf("/tasks/summary/%s", api.memberId), api.member, nil).expect(http.StatusInternalServerError, "database")
+}
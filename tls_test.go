@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePair writes a new self-signed certificate and its key to the files,
+// dated modTime, and returns the DER of the certificate.
+func writePair(t *testing.T, certFile, keyFile string, modTime time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), modTime)
+	writeFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), modTime)
+	return der
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+
+	err := os.WriteFile(path, data, 0o600)
+	if err == nil {
+		err = os.Chtimes(path, modTime, modTime)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// served returns the DER of the certificate served by c.
+func served(t *testing.T, c *certReloader) []byte {
+	t.Helper()
+
+	cert, err := c.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert.Certificate[0]
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	modTime := time.Now().Add(-time.Hour)
+
+	_, err := newCertReloader(certFile, keyFile)
+	if err == nil {
+		t.Fatal("expected missing files rejected")
+	}
+
+	first := writePair(t, certFile, keyFile, modTime)
+	c, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(served(t, c), first) {
+		t.Fatal("expected the first certificate served")
+	}
+
+	reloaded, err := c.reload()
+	if err != nil || reloaded {
+		t.Fatalf("expected unchanged files skipped, got %t, %v", reloaded, err)
+	}
+
+	modTime = modTime.Add(time.Minute)
+	second := writePair(t, certFile, keyFile, modTime)
+	reloaded, err = c.reload()
+	if err != nil || !reloaded {
+		t.Fatalf("expected changed files reloaded, got %t, %v", reloaded, err)
+	}
+	if !bytes.Equal(served(t, c), second) {
+		t.Fatal("expected the second certificate served")
+	}
+
+	// a certificate that doesn't match the key, as if written halfway
+	modTime = modTime.Add(time.Minute)
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePair(t, certFile, keyFile, modTime)
+	writeFile(t, keyFile, keyPEM, modTime)
+	_, err = c.reload()
+	if err == nil {
+		t.Fatal("expected the invalid pair rejected")
+	}
+	if !bytes.Equal(served(t, c), second) {
+		t.Fatal("expected the previous certificate kept")
+	}
+}
+
+func TestCertReloaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	modTime := time.Now().Add(-time.Hour)
+
+	writePair(t, certFile, keyFile, modTime)
+	c, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Watch(ctx, 5*time.Millisecond)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	renewed := writePair(t, certFile, keyFile, modTime.Add(time.Minute))
+	deadline := time.Now().Add(5 * time.Second)
+	for !bytes.Equal(served(t, c), renewed) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the renewed certificate picked up")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}